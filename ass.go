@@ -0,0 +1,369 @@
+/*
+
+This file implements the SSA/ASS (Advanced SubStation Alpha) Format.
+
+Only a practical subset of ASS is supported: a single "Default" style,
+the \anX alignment tag and inline \pos(x,y) (both mapped to Pos, \pos
+bucketed into the nearest of the 9 \an zones using PlayResX/PlayResY),
+the \c&HBBGGRR& color tag (mapped to Color), the \b, \i, \u inline style
+tags (mapped to their HTML equivalents so they survive in the generic
+Lines field), and the \k/\K/\kf karaoke tags (mapped to Subtitle.Words,
+see karaoke.go). Anything else (e.g. drawing commands) is simply
+stripped on read.
+
+*/
+
+package srtgears
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// assFormat implements Format for the SSA/ASS subtitle format.
+type assFormat struct{}
+
+func (assFormat) Read(r io.Reader) (*SubsPack, error) {
+	sp := &SubsPack{}
+
+	scanner := bufio.NewScanner(r)
+	section := ""
+	var format []string
+	// ASS defaults when [Script Info] has no PlayResX/PlayResY, matching Write.
+	playResX, playResY := 384.0, 288.0
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "["):
+			section = line
+			continue
+		case section == "[Script Info]":
+			readASSScriptInfoLine(sp, line, &playResX, &playResY)
+		case section == "[Events]" && strings.HasPrefix(line, "Format:"):
+			format = splitASSFields(strings.TrimPrefix(line, "Format:"))
+		case section == "[Events]" && strings.HasPrefix(line, "Dialogue:"):
+			sub, err := parseASSDialogueLine(format, strings.TrimPrefix(line, "Dialogue:"), playResX, playResY)
+			if err != nil {
+				return nil, err
+			}
+			sp.Subs = append(sp.Subs, sub)
+		}
+	}
+
+	return sp, scanner.Err()
+}
+
+func readASSScriptInfoLine(sp *SubsPack, line string, playResX, playResY *float64) {
+	kv := strings.SplitN(line, ":", 2)
+	if len(kv) != 2 {
+		return
+	}
+	key, val := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+	switch key {
+	case "Title":
+		sp.Metadata.Title = val
+	case "Language", "YCbCr Matrix Language":
+		sp.Metadata.Language = val
+	case "PlayResX":
+		if v, err := strconv.ParseFloat(val, 64); err == nil {
+			*playResX = v
+		}
+	case "PlayResY":
+		if v, err := strconv.ParseFloat(val, 64); err == nil {
+			*playResY = v
+		}
+	}
+}
+
+// splitASSFields splits a comma-separated ASS field list, trimming whitespace.
+func splitASSFields(s string) []string {
+	parts := strings.Split(s, ",")
+	for i, p := range parts {
+		parts[i] = strings.TrimSpace(p)
+	}
+	return parts
+}
+
+// parseASSDialogueLine parses the fields of a "Dialogue:" line according to
+// the preceding "Format:" line. The last field (Text) may itself contain commas.
+func parseASSDialogueLine(format []string, rest string, playResX, playResY float64) (*Subtitle, error) {
+	fields := strings.SplitN(rest, ",", len(format))
+	if len(fields) != len(format) {
+		return nil, fmt.Errorf("srtgears: ASS dialogue line has %d fields, want %d", len(fields), len(format))
+	}
+
+	s := &Subtitle{}
+	var text string
+	for i, name := range format {
+		v := strings.TrimSpace(fields[i])
+		switch name {
+		case "Start":
+			d, err := parseASSTimestamp(v)
+			if err != nil {
+				return nil, err
+			}
+			s.TimeIn = d
+		case "End":
+			d, err := parseASSTimestamp(v)
+			if err != nil {
+				return nil, err
+			}
+			s.TimeOut = d
+		case "Text":
+			text = v
+		}
+	}
+
+	s.Lines, s.Words = parseASSText(text, s, playResX, playResY)
+	return s, nil
+}
+
+var (
+	assAnRegex    = regexp.MustCompile(`^an(\d)$`)
+	assColorRegex = regexp.MustCompile(`^c&H([0-9a-fA-F]{6})&$`)
+	assKRegex     = regexp.MustCompile(`^[kK]f?(\d+)$`)
+	assPosRegex   = regexp.MustCompile(`^pos\((-?[\d.]+),(-?[\d.]+)\)$`)
+)
+
+// posFromCoords buckets a pixel position (x,y) into the nearest of the 9
+// \anX zones (numpad layout, matching the Pos constants), given the
+// script's resolution.
+func posFromCoords(x, y, playResX, playResY float64) Pos {
+	col := 2 // 0=left, 1=center, 2=right
+	switch {
+	case playResX > 0 && x < playResX/3:
+		col = 0
+	case playResX > 0 && x < 2*playResX/3:
+		col = 1
+	}
+	row := 0 // 0=bottom, 1=middle, 2=top
+	switch {
+	case playResY > 0 && y < playResY/3:
+		row = 2
+	case playResY > 0 && y < 2*playResY/3:
+		row = 1
+	}
+	return Pos(row*3 + col + 1)
+}
+
+// parseASSText walks text, a Dialogue line's Text field, splitting it into
+// lines on "\N"/"\n", extracting the leading \an/\c override into s.Pos/
+// s.Color, converting \b/\i/\u into their HTML equivalent, and - if any \k/
+// \K/\kf karaoke tag is present - building the corresponding per-line Words.
+// Each karaoke tag is taken to time the run of text that immediately follows it.
+// playResX/playResY are the script's resolution (from [Script Info]), used to
+// bucket an inline \pos(x,y) into the nearest \anX zone.
+func parseASSText(text string, s *Subtitle, playResX, playResY float64) (lines []string, words [][]Word) {
+	var curLine strings.Builder
+	var curWords []Word
+	var elapsed time.Duration
+	var wordDur time.Duration = -1 // -1: no pending karaoke tag
+	hasKaraoke := false
+
+	flushLine := func() {
+		lines = append(lines, curLine.String())
+		words = append(words, curWords)
+		curLine.Reset()
+		curWords = nil
+	}
+
+	i := 0
+	for i < len(text) {
+		switch {
+		case text[i] == '{':
+			end := strings.IndexByte(text[i:], '}')
+			if end == -1 {
+				i = len(text)
+				continue
+			}
+			body := strings.TrimPrefix(text[i+1:i+end], `\`)
+			for _, tag := range strings.Split(body, `\`) {
+				switch {
+				case tag == "":
+				case assAnRegex.MatchString(tag):
+					n, _ := strconv.Atoi(assAnRegex.FindStringSubmatch(tag)[1])
+					s.Pos = Pos(n)
+				case assPosRegex.MatchString(tag):
+					m := assPosRegex.FindStringSubmatch(tag)
+					x, errX := strconv.ParseFloat(m[1], 64)
+					y, errY := strconv.ParseFloat(m[2], 64)
+					if errX == nil && errY == nil {
+						s.Pos = posFromCoords(x, y, playResX, playResY)
+					}
+				case assColorRegex.MatchString(tag):
+					bgr := assColorRegex.FindStringSubmatch(tag)[1]
+					s.Color = "#" + bgr[4:6] + bgr[2:4] + bgr[0:2] // ASS stores BBGGRR
+				case assKRegex.MatchString(tag):
+					hasKaraoke = true
+					cs, _ := strconv.Atoi(assKRegex.FindStringSubmatch(tag)[1])
+					wordDur = time.Duration(cs) * 10 * time.Millisecond
+				case tag == "b1":
+					curLine.WriteString("<b>")
+				case tag == "b0":
+					curLine.WriteString("</b>")
+				case tag == "i1":
+					curLine.WriteString("<i>")
+				case tag == "i0":
+					curLine.WriteString("</i>")
+				case tag == "u1":
+					curLine.WriteString("<u>")
+				case tag == "u0":
+					curLine.WriteString("</u>")
+					// Anything else (e.g. \fad, drawing commands) is not representable, drop it.
+				}
+			}
+			i += end + 1
+		case strings.HasPrefix(text[i:], `\N`), strings.HasPrefix(text[i:], `\n`):
+			flushLine()
+			i += 2
+		default:
+			j := i
+			for j < len(text) && text[j] != '{' && !strings.HasPrefix(text[j:], `\N`) && !strings.HasPrefix(text[j:], `\n`) {
+				j++
+			}
+			run := text[i:j]
+			if wordDur >= 0 {
+				curWords = append(curWords, Word{Text: strings.TrimSpace(run), Start: elapsed, End: elapsed + wordDur})
+				elapsed += wordDur
+				wordDur = -1
+			}
+			curLine.WriteString(run)
+			i = j
+		}
+	}
+	flushLine()
+
+	if !hasKaraoke {
+		return lines, nil
+	}
+	return lines, words
+}
+
+// parseASSTimestamp parses a "H:MM:SS.cc" ASS timestamp (centiseconds).
+func parseASSTimestamp(s string) (time.Duration, error) {
+	parts := strings.SplitN(s, ".", 2)
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("srtgears: invalid ASS timestamp: %q", s)
+	}
+	hms := strings.Split(parts[0], ":")
+	if len(hms) != 3 {
+		return 0, fmt.Errorf("srtgears: invalid ASS timestamp: %q", s)
+	}
+	h, err := strconv.Atoi(hms[0])
+	if err != nil {
+		return 0, err
+	}
+	m, err := strconv.Atoi(hms[1])
+	if err != nil {
+		return 0, err
+	}
+	sec, err := strconv.Atoi(hms[2])
+	if err != nil {
+		return 0, err
+	}
+	cs, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+		time.Duration(sec)*time.Second + time.Duration(cs)*10*time.Millisecond, nil
+}
+
+// formatASSTimestamp formats d the ASS way: "H:MM:SS.cc".
+func formatASSTimestamp(d time.Duration) string {
+	cs := d / (10 * time.Millisecond)
+	h := cs / (360000)
+	cs -= h * 360000
+	m := cs / 6000
+	cs -= m * 6000
+	s := cs / 100
+	cs -= s * 100
+	return fmt.Sprintf("%d:%02d:%02d.%02d", h, m, s, cs)
+}
+
+func (assFormat) Write(w io.Writer, sp *SubsPack) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "[Script Info]")
+	fmt.Fprintln(bw, "ScriptType: v4.00+")
+	if sp.Metadata.Title != "" {
+		fmt.Fprintf(bw, "Title: %s\n", sp.Metadata.Title)
+	}
+	if sp.Metadata.Language != "" {
+		fmt.Fprintf(bw, "Language: %s\n", sp.Metadata.Language)
+	}
+	fmt.Fprintln(bw, "PlayResX: 384")
+	fmt.Fprintln(bw, "PlayResY: 288")
+	fmt.Fprintln(bw)
+
+	fmt.Fprintln(bw, "[V4+ Styles]")
+	fmt.Fprintln(bw, "Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding")
+	fmt.Fprintln(bw, "Style: Default,Arial,20,&H00FFFFFF,&H000000FF,&H00000000,&H00000000,0,0,0,0,100,100,0,0,1,1,0,2,10,10,10,1")
+	fmt.Fprintln(bw)
+
+	fmt.Fprintln(bw, "[Events]")
+	fmt.Fprintln(bw, "Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text")
+	for _, s := range sp.Subs {
+		fmt.Fprintf(bw, "Dialogue: 0,%s,%s,Default,,0,0,0,,%s\n",
+			formatASSTimestamp(s.TimeIn), formatASSTimestamp(s.TimeOut), linesToASS(s))
+	}
+
+	return bw.Flush()
+}
+
+var htmlTagToASS = map[string]string{
+	"<b>": `{\b1}`, "</b>": `{\b0}`,
+	"<i>": `{\i1}`, "</i>": `{\i0}`,
+	"<u>": `{\u1}`, "</u>": `{\u0}`,
+}
+
+// linesToASS joins a subtitle's lines with ASS's "\N" line break, converts
+// known HTML tags back to ASS override tags (or, if s.Words is populated,
+// emits a {\kNN} tag per word instead), and prepends the \an/\c override
+// block derived from s.Pos/s.Color.
+func linesToASS(s *Subtitle) string {
+	var text string
+	if len(s.Words) == len(s.Lines) && len(s.Words) > 0 {
+		text = strings.Join(karaokeLinesToASS(s.Words), `\N`)
+	} else {
+		text = strings.Join(s.Lines, `\N`)
+		for html, ass := range htmlTagToASS {
+			text = strings.ReplaceAll(text, html, ass)
+		}
+	}
+
+	var prefix strings.Builder
+	if s.Pos != PosUnset {
+		fmt.Fprintf(&prefix, `\an%d`, s.Pos)
+	}
+	if s.Color != "" {
+		if r, g, b, ok := parseHTMLColor(s.Color); ok {
+			fmt.Fprintf(&prefix, `\c&H%02X%02X%02X&`, b, g, r)
+		}
+	}
+	if prefix.Len() > 0 {
+		text = "{" + prefix.String() + "}" + text
+	}
+	return text
+}
+
+// parseHTMLColor parses a "#rrggbb" color string.
+func parseHTMLColor(s string) (r, g, b byte, ok bool) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return byte(v >> 16), byte(v >> 8), byte(v), true
+}
@@ -0,0 +1,194 @@
+/*
+
+This file implements the pluggable subtitle format registry
+and the Open/Save convenience functions built on top of it.
+
+*/
+
+package srtgears
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Metadata holds subtitle-wide information that is not tied to an individual
+// Subtitle. Not all formats can store all fields; unsupported fields are
+// simply left at their zero value when reading, and ignored when writing.
+type Metadata struct {
+	FrameRate float64 // Frame rate the subtitle was authored for, e.g. 25 or 29.97; 0 if unknown
+	Language  string  // Language code, e.g. "en", "hu"; empty if unknown
+	Title     string  // Title / program name; empty if unknown
+}
+
+// Format is implemented by subtitle formats that can be read from
+// and written to a plain byte stream.
+type Format interface {
+	// Read parses a SubsPack from r.
+	Read(r io.Reader) (*SubsPack, error)
+	// Write serializes sp to w.
+	Write(w io.Writer, sp *SubsPack) error
+}
+
+// StreamFormat is implemented by formats that can read and write subtitles
+// incrementally, one at a time, instead of materializing the whole pack in
+// memory. Pipeline.Run uses it, when both the input and output formats
+// support it, so a long file's subtitles never have to fit in memory all
+// at once end-to-end, not just between the pipeline's stages.
+//
+// Streaming intentionally does not carry pack-wide Metadata (Title,
+// Language, FrameRate): formats that need the whole file in hand anyway to
+// make sense of their framing or trailing fields (an XML root element, a
+// byte-counted GSI header) are plain Formats, not StreamFormats, and
+// Pipeline.Run falls back to their buffered Read/Write, which does
+// preserve Metadata.
+type StreamFormat interface {
+	Format
+	// ReadStream parses r one subtitle at a time, sending each to out and
+	// closing out when done, whether that's because of a clean EOF or an
+	// error.
+	ReadStream(r io.Reader, out chan<- *Subtitle) error
+	// WriteStream writes subtitles from in to w as they arrive.
+	WriteStream(w io.Writer, in <-chan *Subtitle) error
+}
+
+// formats holds the registered formats, keyed by their lower-case name.
+var formats = map[string]Format{}
+
+// extFormats maps a lower-case file extension (including the leading dot)
+// to the name of the format registered for it.
+var extFormats = map[string]string{}
+
+// RegisterFormat registers f under name, associating it with the given file
+// extensions (each including the leading dot, e.g. ".srt"). Registering under
+// a name or extension that is already registered overwrites the previous one.
+func RegisterFormat(name string, extensions []string, f Format) {
+	name = strings.ToLower(name)
+	formats[name] = f
+	for _, ext := range extensions {
+		extFormats[strings.ToLower(ext)] = name
+	}
+}
+
+func init() {
+	RegisterFormat("srt", []string{".srt"}, srtFormat{})
+	RegisterFormat("vtt", []string{".vtt"}, vttFormat{})
+	RegisterFormat("ass", []string{".ass", ".ssa"}, assFormat{})
+	RegisterFormat("stl", []string{".stl"}, stlFormat{})
+	RegisterFormat("ttml", []string{".ttml", ".dfxp"}, ttmlFormat{})
+}
+
+// FormatByName returns the Format registered under name.
+func FormatByName(name string) (Format, error) {
+	f, ok := formats[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("srtgears: unknown subtitle format: %q", name)
+	}
+	return f, nil
+}
+
+// FormatByExt returns the name and Format registered for filename's extension.
+func FormatByExt(filename string) (name string, f Format, err error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	name, ok := extFormats[ext]
+	if !ok {
+		return "", nil, fmt.Errorf("srtgears: unrecognized subtitle extension: %q", ext)
+	}
+	return name, formats[name], nil
+}
+
+// Open reads a SubsPack from filename, choosing the Format by its extension.
+func Open(filename string) (*SubsPack, error) {
+	_, f, err := FormatByExt(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return f.Read(file)
+}
+
+// detectSniffSize is how much of the input Detect looks at.
+const detectSniffSize = 4096
+
+// Detect sniffs the format of r by inspecting its first few KB, without
+// requiring a filename or extension. A UTF-16 BOM, if present, is stripped
+// and the remainder transcoded to UTF-8 before sniffing, the same as
+// ReadSRT tolerates. Detect returns a replacement reader that replays the
+// sniffed (and, for UTF-16 input, transcoded) bytes followed by the rest of
+// r, so callers can pass it straight to the detected Format's Read without
+// losing the bytes Detect consumed.
+func Detect(r io.Reader) (formatName string, out io.Reader, err error) {
+	r, err = stripBOM(r)
+	if err != nil {
+		return "", nil, err
+	}
+
+	buf := make([]byte, detectSniffSize)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", nil, err
+	}
+	buf = buf[:n]
+	out = io.MultiReader(bytes.NewReader(buf), r)
+
+	switch {
+	case looksLikeSTL(buf):
+		return "stl", out, nil
+	case bytes.HasPrefix(bytes.TrimSpace(buf), []byte("WEBVTT")):
+		return "vtt", out, nil
+	case bytes.Contains(buf, []byte("[Script Info]")):
+		return "ass", out, nil
+	case looksLikeSRT(buf):
+		return "srt", out, nil
+	}
+	return "", out, fmt.Errorf("srtgears: could not detect subtitle format")
+}
+
+// srtDetectMaxLines bounds how many leading lines looksLikeSRT scans looking
+// for a timing line, tolerating a run of (possibly duplicated) sequence-number
+// lines before it the same way ReadSRT does.
+const srtDetectMaxLines = 5
+
+// looksLikeSRT reports whether buf starts with zero or more bare
+// sequence-number lines followed by an SRT timing line.
+func looksLikeSRT(buf []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for i := 0; i < srtDetectMaxLines && scanner.Scan(); i++ {
+		line := scanner.Text()
+		if srtTimingLineRegex.MatchString(line) {
+			return true
+		}
+		if _, err := strconv.Atoi(strings.TrimSpace(line)); err != nil {
+			return false
+		}
+	}
+	return false
+}
+
+// Save writes sp to filename, choosing the Format by its extension.
+func Save(filename string, sp *SubsPack) error {
+	_, f, err := FormatByExt(filename)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return f.Write(file, sp)
+}
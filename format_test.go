@@ -0,0 +1,208 @@
+package srtgears
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatByExt(t *testing.T) {
+	cases := map[string]string{
+		"movie.srt":  "srt",
+		"movie.SRT":  "srt",
+		"movie.vtt":  "vtt",
+		"movie.ass":  "ass",
+		"movie.ssa":  "ass",
+		"movie.stl":  "stl",
+		"movie.ttml": "ttml",
+		"movie.dfxp": "ttml",
+	}
+	for filename, want := range cases {
+		name, f, err := FormatByExt(filename)
+		if err != nil {
+			t.Errorf("FormatByExt(%q): unexpected error: %v", filename, err)
+			continue
+		}
+		if name != want {
+			t.Errorf("FormatByExt(%q) = %q, want %q", filename, name, want)
+		}
+		if f == nil {
+			t.Errorf("FormatByExt(%q): nil Format", filename)
+		}
+	}
+
+	if _, _, err := FormatByExt("movie.xyz"); err == nil {
+		t.Error("FormatByExt(\"movie.xyz\"): expected error, got nil")
+	}
+}
+
+func TestSTLRoundTrip(t *testing.T) {
+	sp := &SubsPack{
+		Metadata: Metadata{Language: "en", Title: "My Movie"},
+		Subs: []*Subtitle{
+			{TimeIn: 1 * time.Second, TimeOut: 3 * time.Second, Lines: []string{"Hello", "World"}},
+			{TimeIn: 4 * time.Second, TimeOut: 6500 * time.Millisecond, Lines: []string{"Second line"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (stlFormat{}).Write(&buf, sp); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	got, err := (stlFormat{}).Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	if got.Metadata.Title != sp.Metadata.Title {
+		t.Errorf("Metadata.Title = %q, want %q", got.Metadata.Title, sp.Metadata.Title)
+	}
+	if got.Metadata.Language != sp.Metadata.Language {
+		t.Errorf("Metadata.Language = %q, want %q", got.Metadata.Language, sp.Metadata.Language)
+	}
+	if len(got.Subs) != len(sp.Subs) {
+		t.Fatalf("len(Subs) = %d, want %d", len(got.Subs), len(sp.Subs))
+	}
+	for i, s := range sp.Subs {
+		if strings.Join(got.Subs[i].Lines, "|") != strings.Join(s.Lines, "|") {
+			t.Errorf("Subs[%d].Lines = %v, want %v", i, got.Subs[i].Lines, s.Lines)
+		}
+	}
+}
+
+func TestSTLTitleFieldOffset(t *testing.T) {
+	// The Programme Title (OPT) field is GSI bytes 16..47; Write must put
+	// Metadata.Title exactly there.
+	sp := &SubsPack{Metadata: Metadata{Title: "Title"}}
+	var buf bytes.Buffer
+	if err := (stlFormat{}).Write(&buf, sp); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	gsi := buf.Bytes()[:gsiBlockSize]
+	got := strings.TrimSpace(string(gsi[16:48]))
+	if got != "Title" {
+		t.Errorf("GSI[16:48] (OPT) = %q, want %q", got, "Title")
+	}
+}
+
+func TestTTMLEscaping(t *testing.T) {
+	sp := &SubsPack{
+		Subs: []*Subtitle{
+			{TimeIn: 0, TimeOut: time.Second, Lines: []string{"Tom & Jerry", "a < b & c > d"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (ttmlFormat{}).Write(&buf, sp); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "Tom & Jerry") {
+		t.Errorf("unescaped %q written verbatim into XML:\n%s", "Tom & Jerry", buf.String())
+	}
+
+	got, err := (ttmlFormat{}).Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v (escaped text should still be parseable XML)", err)
+	}
+	if len(got.Subs) != 1 || strings.Join(got.Subs[0].Lines, "|") != "Tom & Jerry|a < b & c > d" {
+		t.Errorf("round-tripped Lines = %v, want original text back", got.Subs[0].Lines)
+	}
+}
+
+func TestASSRoundTrip(t *testing.T) {
+	sp := &SubsPack{
+		Metadata: Metadata{Title: "Demo"},
+		Subs: []*Subtitle{
+			{TimeIn: 1500 * time.Millisecond, TimeOut: 3 * time.Second, Pos: PosTopCenter, Color: "#ff0000", Lines: []string{"Hi"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (assFormat{}).Write(&buf, sp); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	got, err := (assFormat{}).Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(got.Subs) != 1 {
+		t.Fatalf("len(Subs) = %d, want 1", len(got.Subs))
+	}
+	gs := got.Subs[0]
+	if gs.Pos != PosTopCenter {
+		t.Errorf("Pos = %v, want %v", gs.Pos, PosTopCenter)
+	}
+	if !strings.EqualFold(gs.Color, "#ff0000") {
+		t.Errorf("Color = %q, want %q", gs.Color, "#ff0000")
+	}
+	if strings.Join(gs.Lines, "|") != "Hi" {
+		t.Errorf("Lines = %v, want [Hi]", gs.Lines)
+	}
+}
+
+func TestASSInlinePosMapsToPos(t *testing.T) {
+	const in = "[Script Info]\n" +
+		"PlayResX: 384\n" +
+		"PlayResY: 288\n" +
+		"\n" +
+		"[Events]\n" +
+		"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+		`Dialogue: 0,0:00:01.00,0:00:03.00,Default,,0,0,0,,{\pos(10,10)}Top left corner` + "\n"
+
+	sp, err := (assFormat{}).Read(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(sp.Subs) != 1 {
+		t.Fatalf("len(Subs) = %d, want 1", len(sp.Subs))
+	}
+	if sp.Subs[0].Pos != PosTopLeft {
+		t.Errorf("Pos = %v, want %v (pos(10,10) is in the top-left ninth of a 384x288 script)", sp.Subs[0].Pos, PosTopLeft)
+	}
+}
+
+func TestTTMLFrameRateRoundTrip(t *testing.T) {
+	sp := &SubsPack{
+		Metadata: Metadata{FrameRate: 29.97},
+		Subs:     []*Subtitle{{TimeIn: 0, TimeOut: time.Second, Lines: []string{"Hello"}}},
+	}
+
+	var buf bytes.Buffer
+	if err := (ttmlFormat{}).Write(&buf, sp); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	got, err := (ttmlFormat{}).Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got.Metadata.FrameRate != 29.97 {
+		t.Errorf("Metadata.FrameRate = %v, want 29.97", got.Metadata.FrameRate)
+	}
+}
+
+func TestOpenSaveRoundTrip(t *testing.T) {
+	sp := &SubsPack{
+		Subs: []*Subtitle{
+			{TimeIn: time.Second, TimeOut: 2 * time.Second, Lines: []string{"Hello"}},
+		},
+	}
+
+	dir := t.TempDir()
+	filename := dir + "/out.vtt"
+	if err := Save(filename, sp); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := Open(filename)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if len(got.Subs) != 1 || strings.Join(got.Subs[0].Lines, "|") != "Hello" {
+		t.Errorf("round-tripped Subs = %v", got.Subs)
+	}
+}
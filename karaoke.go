@@ -0,0 +1,152 @@
+/*
+
+This file implements karaoke-style per-word timing: synthesizing Words from
+plain text (DistributeWords), and an SRT writer that highlights the
+currently-spoken word using it (WriteSRTKaraoke).
+
+*/
+
+package srtgears
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DistributeWords synthesizes per-word timing (Words) for every subtitle in
+// sp whose Lines are plain text. See Subtitle.DistributeWords.
+func (sp *SubsPack) DistributeWords() {
+	for _, s := range sp.Subs {
+		s.DistributeWords()
+	}
+}
+
+// DistributeWords synthesizes per-word timing for s by distributing its
+// display duration across its words proportionally to each word's character
+// count; whitespace is skipped and does not consume any time.
+func (s *Subtitle) DistributeWords() {
+	lineWords := make([][]string, len(s.Lines))
+	totalChars := 0
+	for i, line := range s.Lines {
+		lineWords[i] = strings.Fields(line)
+		for _, w := range lineWords[i] {
+			totalChars += utf8.RuneCountInString(w)
+		}
+	}
+	if totalChars == 0 {
+		return
+	}
+
+	dur := s.DisplayDuration()
+	s.Words = make([][]Word, len(s.Lines))
+	var elapsed time.Duration
+	for i, words := range lineWords {
+		ws := make([]Word, len(words))
+		for j, w := range words {
+			chars := utf8.RuneCountInString(w)
+			wordDur := time.Duration(float64(dur) * float64(chars) / float64(totalChars))
+			ws[j] = Word{Text: w, Start: elapsed, End: elapsed + wordDur}
+			elapsed += wordDur
+		}
+		s.Words[i] = ws
+	}
+}
+
+// WriteSRTKaraoke writes sp as SRT, splitting every subtitle that has Words
+// into one entry per word so that players show them one at a time, with the
+// currently-spoken word wrapped in <font color="..."> using s.Color (falling
+// back to yellow if unset). Subtitles without Words are written unchanged.
+func WriteSRTKaraoke(w io.Writer, sp *SubsPack) error {
+	bw := bufio.NewWriter(w)
+	n := 0
+
+	writeEntry := func(timeIn, timeOut time.Duration, lines []string) {
+		n++
+		fmt.Fprintf(bw, "%d\n", n)
+		fmt.Fprintf(bw, "%s --> %s\n", formatSRTTimestamp(timeIn), formatSRTTimestamp(timeOut))
+		for _, line := range lines {
+			fmt.Fprintln(bw, line)
+		}
+		fmt.Fprintln(bw)
+	}
+
+	for _, s := range sp.Subs {
+		if len(s.Words) == 0 {
+			writeEntry(s.TimeIn, s.TimeOut, s.Lines)
+			continue
+		}
+		for li, words := range s.Words {
+			for wi := range words {
+				lines := make([]string, len(s.Lines))
+				copy(lines, s.Lines)
+				lines[li] = highlightWord(lines[li], wi, s.Color)
+				writeEntry(s.TimeIn+words[wi].Start, s.TimeIn+words[wi].End, lines)
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// karaokeLinesToASS renders each line's Words as a run of "{\kNN}word " ASS
+// karaoke tags, NN being the word's duration in centiseconds.
+func karaokeLinesToASS(lineWords [][]Word) []string {
+	lines := make([]string, len(lineWords))
+	for i, words := range lineWords {
+		var b strings.Builder
+		for _, w := range words {
+			cs := (w.End - w.Start) / (10 * time.Millisecond)
+			fmt.Fprintf(&b, `{\k%d}%s `, cs, w.Text)
+		}
+		lines[i] = strings.TrimRight(b.String(), " ")
+	}
+	return lines
+}
+
+// highlightWord wraps the wordIndex-th whitespace-delimited field of line
+// (the same fields DistributeWords enumerates via strings.Fields) with an
+// HTML font tag, so repeated words ("la la la") each highlight in place
+// instead of all resolving to the first occurrence.
+func highlightWord(line string, wordIndex int, color string) string {
+	start, length, ok := nthFieldRange(line, wordIndex)
+	if !ok {
+		return line
+	}
+	if color == "" {
+		color = "#ffff00"
+	}
+	word := line[start : start+length]
+	return line[:start] + fmt.Sprintf(`<font color="%s">%s</font>`, color, word) + line[start+length:]
+}
+
+// nthFieldRange returns the byte range of the n-th (0-based) whitespace-delimited
+// field in line, using the same field boundaries as strings.Fields.
+func nthFieldRange(line string, n int) (start, length int, ok bool) {
+	idx := 0
+	i := 0
+	for i < len(line) {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+		fieldStart := i
+		for i < len(line) {
+			r, size := utf8.DecodeRuneInString(line[i:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			i += size
+		}
+		if idx == n {
+			return fieldStart, i - fieldStart, true
+		}
+		idx++
+	}
+	return 0, 0, false
+}
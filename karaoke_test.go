@@ -0,0 +1,95 @@
+package srtgears
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDistributeWords(t *testing.T) {
+	s := &Subtitle{TimeIn: 0, TimeOut: 10 * time.Second, Lines: []string{"ab cd", "efgh"}}
+	s.DistributeWords()
+
+	if len(s.Words) != 2 {
+		t.Fatalf("len(Words) = %d, want 2", len(s.Words))
+	}
+	if len(s.Words[0]) != 2 || len(s.Words[1]) != 1 {
+		t.Fatalf("Words = %+v, want 2 words on line 0 and 1 on line 1", s.Words)
+	}
+	if s.Words[0][0].Text != "ab" || s.Words[0][1].Text != "cd" {
+		t.Errorf("line 0 words = %q, %q, want ab, cd", s.Words[0][0].Text, s.Words[0][1].Text)
+	}
+	// "ab", "cd", "efgh" are 2, 2, 4 non-space chars out of 8 total, over a 10s span.
+	if s.Words[0][0].End-s.Words[0][0].Start != 2500*time.Millisecond {
+		t.Errorf("Words[0][0] duration = %v, want 2.5s", s.Words[0][0].End-s.Words[0][0].Start)
+	}
+	if s.Words[1][0].Start != s.Words[0][1].End {
+		t.Errorf("Words[1][0].Start = %v, want it to continue right after Words[0][1].End (%v)", s.Words[1][0].Start, s.Words[0][1].End)
+	}
+}
+
+func TestHighlightWordRepeatedWords(t *testing.T) {
+	line := "la la la"
+	got0 := highlightWord(line, 0, "#ff0000")
+	got1 := highlightWord(line, 1, "#ff0000")
+	got2 := highlightWord(line, 2, "#ff0000")
+
+	want0 := `<font color="#ff0000">la</font> la la`
+	want1 := `la <font color="#ff0000">la</font> la`
+	want2 := `la la <font color="#ff0000">la</font>`
+
+	if got0 != want0 {
+		t.Errorf("highlightWord(line, 0, ...) = %q, want %q", got0, want0)
+	}
+	if got1 != want1 {
+		t.Errorf("highlightWord(line, 1, ...) = %q, want %q", got1, want1)
+	}
+	if got2 != want2 {
+		t.Errorf("highlightWord(line, 2, ...) = %q, want %q", got2, want2)
+	}
+}
+
+func TestWriteSRTKaraokeRepeatedWords(t *testing.T) {
+	s := &Subtitle{TimeIn: 0, TimeOut: 3 * time.Second, Lines: []string{"no no never"}}
+	s.DistributeWords()
+
+	var buf bytes.Buffer
+	if err := WriteSRTKaraoke(&buf, &SubsPack{Subs: []*Subtitle{s}}); err != nil {
+		t.Fatalf("WriteSRTKaraoke: %v", err)
+	}
+
+	out := buf.String()
+	entries := strings.Split(strings.TrimSpace(out), "\n\n")
+	if len(entries) != 3 {
+		t.Fatalf("got %d SRT entries, want 3 (one per word)", len(entries))
+	}
+	if !strings.Contains(entries[1], `<font color="#ffff00">no</font> never`) {
+		t.Errorf("second entry = %q, want the *second* \"no\" highlighted", entries[1])
+	}
+}
+
+func TestASSKaraokeRoundTrip(t *testing.T) {
+	s := &Subtitle{TimeIn: 0, TimeOut: 2 * time.Second, Lines: []string{"ab cd"}}
+	s.DistributeWords()
+	sp := &SubsPack{Subs: []*Subtitle{s}}
+
+	var buf bytes.Buffer
+	if err := (assFormat{}).Write(&buf, sp); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if !strings.Contains(buf.String(), `\k`) {
+		t.Fatalf("ASS output has no \\k karaoke tag:\n%s", buf.String())
+	}
+
+	got, err := (assFormat{}).Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(got.Subs) != 1 || len(got.Subs[0].Words) != 1 || len(got.Subs[0].Words[0]) != 2 {
+		t.Fatalf("round-tripped Words = %+v, want 1 line of 2 words", got.Subs[0].Words)
+	}
+	if got.Subs[0].Words[0][0].Text != "ab" || got.Subs[0].Words[0][1].Text != "cd" {
+		t.Errorf("round-tripped words = %q, %q, want ab, cd", got.Subs[0].Words[0][0].Text, got.Subs[0].Words[0][1].Text)
+	}
+}
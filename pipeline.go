@@ -0,0 +1,198 @@
+/*
+
+This file implements a streaming, stage-based transform pipeline for
+subtitles, for callers that would rather not hold an entire multi-hour
+subtitle file's worth of Subtitle values in memory as a []*Subtitle.
+
+When inFmt and outFmt both implement StreamFormat (currently SRT and
+WebVTT), Pipeline.Run streams end to end: subtitles flow from the reader,
+through the stages, to the writer one at a time over channels, and the
+whole file's subtitles are never all resident in memory at once. Otherwise
+Run falls back to the plain Format.Read/Write for whichever side doesn't
+support streaming, materializing that side's *SubsPack in full, the same
+as before StreamFormat existed; the transform in between always streams.
+
+*/
+
+package srtgears
+
+import (
+	"io"
+	"time"
+)
+
+// Stage is a single step of a Pipeline: it reads subtitles from in until
+// it's closed, and writes (zero or more, in any order it likes) subtitles
+// to out. Run closes out once the stage returns, so a stage must not close
+// it itself. Built-in stages below only ever
+// write at most one output per input (they transform or drop subtitles),
+// but custom stages are free to do otherwise (e.g. batching).
+type Stage func(in <-chan *Subtitle, out chan<- *Subtitle)
+
+// pipelineChanBuf is the buffer size used for the channels between stages.
+const pipelineChanBuf = 16
+
+// pipeline is a chain of Stages, built with Pipeline.
+type pipeline struct {
+	stages []Stage
+}
+
+// Pipeline builds a pipeline that runs subtitles through stages in order.
+func Pipeline(stages ...Stage) pipeline {
+	return pipeline{stages: stages}
+}
+
+// Run reads subtitles from r using inFmt, streams them through the
+// pipeline's stages, and writes the result to w using outFmt. As noted
+// above, this only avoids materializing the whole file in memory when both
+// inFmt and outFmt implement StreamFormat; otherwise the non-streaming
+// side is read or written in full, same as a plain Format always was.
+func (p pipeline) Run(r io.Reader, w io.Writer, inFmt, outFmt Format) error {
+	in := make(chan *Subtitle, pipelineChanBuf)
+	readErrCh := make(chan error, 1)
+
+	var md Metadata
+	if inSF, ok := inFmt.(StreamFormat); ok {
+		go func() { readErrCh <- inSF.ReadStream(r, in) }()
+	} else {
+		sp, err := inFmt.Read(r)
+		if err != nil {
+			return err
+		}
+		md = sp.Metadata
+		go func() {
+			defer close(in)
+			for _, s := range sp.Subs {
+				in <- s
+			}
+			readErrCh <- nil
+		}()
+	}
+
+	cur := (<-chan *Subtitle)(in)
+	for _, stage := range p.stages {
+		out := make(chan *Subtitle, pipelineChanBuf)
+		go func(stage Stage, in <-chan *Subtitle, out chan<- *Subtitle) {
+			defer close(out)
+			stage(in, out)
+		}(stage, cur, out)
+		cur = out
+	}
+
+	var writeErr error
+	if outSF, ok := outFmt.(StreamFormat); ok {
+		writeErr = outSF.WriteStream(w, cur)
+	} else {
+		sp := &SubsPack{Metadata: md}
+		for s := range cur {
+			sp.Subs = append(sp.Subs, s)
+		}
+		writeErr = outFmt.Write(w, sp)
+	}
+
+	if err := <-readErrCh; err != nil {
+		return err
+	}
+	return writeErr
+}
+
+// passthrough returns a Stage that applies fn to every subtitle and forwards it.
+func passthrough(fn func(s *Subtitle)) Stage {
+	return func(in <-chan *Subtitle, out chan<- *Subtitle) {
+		for s := range in {
+			fn(s)
+			out <- s
+		}
+	}
+}
+
+// Shift returns a Stage that shifts every subtitle by delta. See SubsPack.Shift.
+func Shift(delta time.Duration) Stage {
+	return passthrough(func(s *Subtitle) { s.Shift(delta) })
+}
+
+// Scale returns a Stage that scales every subtitle's timestamps by factor. See SubsPack.Scale.
+func Scale(factor float64) Stage {
+	return passthrough(func(s *Subtitle) { s.Scale(factor) })
+}
+
+// Lengthen returns a Stage that lengthens every subtitle's display duration by factor. See SubsPack.Lengthen.
+func Lengthen(factor float64) Stage {
+	return passthrough(func(s *Subtitle) { s.Lengthen(factor) })
+}
+
+// SetPos returns a Stage that sets every subtitle's position. See SubsPack.SetPos.
+func SetPos(pos Pos) Stage {
+	return passthrough(func(s *Subtitle) { s.Pos = pos })
+}
+
+// SetColor returns a Stage that sets every subtitle's color. See SubsPack.SetColor.
+func SetColor(color string) Stage {
+	return passthrough(func(s *Subtitle) { s.Color = color })
+}
+
+// RemoveHTML returns a Stage that removes HTML formatting from every subtitle. See SubsPack.RemoveHTML.
+func RemoveHTML() Stage {
+	return passthrough(func(s *Subtitle) { s.RemoveHTML() })
+}
+
+// RemoveControl returns a Stage that removes controls from every subtitle. See SubsPack.RemoveControl.
+func RemoveControl() Stage {
+	return passthrough(func(s *Subtitle) { s.RemoveControl() })
+}
+
+// RemoveHI returns a Stage that removes hearing-impaired lines from
+// every subtitle, dropping subtitles that end up with no lines left. See SubsPack.RemoveHI.
+func RemoveHI() Stage {
+	return func(in <-chan *Subtitle, out chan<- *Subtitle) {
+		for s := range in {
+			s.RemoveHI()
+			if len(s.Lines) == 0 {
+				continue
+			}
+			out <- s
+		}
+	}
+}
+
+// Stats returns a Stage that accumulates statistics into dst as
+// subtitles flow through it, unchanged, exactly like SubsPack.Stats does
+// for an in-memory pack. dst is only complete once the input channel (and
+// so this stage's output channel) has been fully drained.
+func Stats(dst *SubsStats) Stage {
+	return func(in <-chan *Subtitle, out chan<- *Subtitle) {
+		var pending *Subtitle
+		var pendingOffender bool
+		var lastTimeOut time.Duration
+
+		for s := range in {
+			// addSub strips controls, HTML and HI lines from what it analyzes,
+			// so it gets a copy and s itself is forwarded unchanged.
+			c := *s
+			c.Lines = append([]string(nil), s.Lines...)
+			offender := dst.addSub(&c)
+			if pending != nil {
+				gap := s.TimeIn - pending.TimeOut
+				switch {
+				case gap < 0:
+					dst.Overlaps++
+					pendingOffender = true
+				case gap < DefaultMinGap:
+					dst.ShortGaps++
+					pendingOffender = true
+				}
+				if pendingOffender {
+					dst.Offenders = append(dst.Offenders, pending)
+				}
+			}
+			pending, pendingOffender = s, offender
+			lastTimeOut = s.TimeOut
+			out <- s
+		}
+
+		if pending != nil && pendingOffender {
+			dst.Offenders = append(dst.Offenders, pending)
+		}
+		dst.finalizeAverages(lastTimeOut)
+	}
+}
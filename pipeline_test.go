@@ -0,0 +1,110 @@
+package srtgears
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPipelineRun(t *testing.T) {
+	const in = "WEBVTT\n\n" +
+		"00:00:01.000 --> 00:00:04.000\n[PHONE RINGING]\n\n" +
+		"00:00:05.000 --> 00:00:08.000\nWorld\n"
+
+	var out bytes.Buffer
+	p := Pipeline(Shift(time.Second), RemoveHI(), SetColor("#00ff00"))
+	if err := p.Run(strings.NewReader(in), &out, vttFormat{}, vttFormat{}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	sp, err := (vttFormat{}).Read(&out)
+	if err != nil {
+		t.Fatalf("re-reading pipeline output: %v", err)
+	}
+	if len(sp.Subs) != 1 {
+		t.Fatalf("len(Subs) = %d, want 1 (the hearing-impaired-only subtitle should be dropped)", len(sp.Subs))
+	}
+	if sp.Subs[0].TimeIn != 6*time.Second {
+		t.Errorf("TimeIn = %v, want 6s (5s + 1s Shift)", sp.Subs[0].TimeIn)
+	}
+	if sp.Subs[0].Lines[0] != "World" {
+		t.Errorf("Lines = %v, want [World]", sp.Subs[0].Lines)
+	}
+}
+
+func TestPipelineRunStreamsWithoutMetadata(t *testing.T) {
+	const in = "1\n00:00:01,000 --> 00:00:04,000\nHello\n"
+
+	var out bytes.Buffer
+	p := Pipeline(Shift(time.Second))
+	if err := p.Run(strings.NewReader(in), &out, srtFormat{}, srtFormat{}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	sp, err := (srtFormat{}).Read(&out)
+	if err != nil {
+		t.Fatalf("re-reading pipeline output: %v", err)
+	}
+	if len(sp.Subs) != 1 || sp.Subs[0].TimeIn != 2*time.Second {
+		t.Errorf("Subs = %+v, want a single subtitle shifted to 2s", sp.Subs)
+	}
+}
+
+func TestPipelineRunFallsBackForNonStreamingFormat(t *testing.T) {
+	sp := &SubsPack{
+		Metadata: Metadata{Title: "Demo"},
+		Subs:     []*Subtitle{{TimeIn: time.Second, TimeOut: 2 * time.Second, Lines: []string{"Hi"}}},
+	}
+	var src bytes.Buffer
+	if err := (assFormat{}).Write(&src, sp); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	var out bytes.Buffer
+	p := Pipeline(Shift(time.Second))
+	if err := p.Run(&src, &out, assFormat{}, assFormat{}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got, err := (assFormat{}).Read(&out)
+	if err != nil {
+		t.Fatalf("re-reading pipeline output: %v", err)
+	}
+	if got.Metadata.Title != "Demo" {
+		t.Errorf("Metadata.Title = %q, want %q (non-streaming formats must still preserve Metadata)", got.Metadata.Title, "Demo")
+	}
+}
+
+func TestPipelineStats(t *testing.T) {
+	const in = "WEBVTT\n\n" +
+		"00:00:01.000 --> 00:00:02.000\nOne\n\n" +
+		"00:00:03.000 --> 00:00:04.000\nTwo\n"
+
+	var stats SubsStats
+	var out bytes.Buffer
+	p := Pipeline(Stats(&stats))
+	if err := p.Run(strings.NewReader(in), &out, vttFormat{}, vttFormat{}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if stats.Subs != 2 {
+		t.Errorf("Stats.Subs = %d, want 2", stats.Subs)
+	}
+}
+
+func TestRemoveHICompaction(t *testing.T) {
+	sp := &SubsPack{Subs: []*Subtitle{
+		{Lines: []string{"[NOISE]"}},
+		{Lines: []string{"Keep me"}},
+		{Lines: []string{"(sigh)"}},
+		{Lines: []string{"Keep me too"}},
+	}}
+	sp.RemoveHI()
+
+	if len(sp.Subs) != 2 {
+		t.Fatalf("len(Subs) = %d, want 2", len(sp.Subs))
+	}
+	if sp.Subs[0].Lines[0] != "Keep me" || sp.Subs[1].Lines[0] != "Keep me too" {
+		t.Errorf("Subs = %+v, want the two non-HI subtitles in order", sp.Subs)
+	}
+}
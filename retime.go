@@ -0,0 +1,153 @@
+/*
+
+This file implements reading-speed analysis (used by SubsStats) and
+SubsPack.Retime, an automatic QC pass that adjusts subtitle timing to
+stay within reasonable reading-speed and gap constraints.
+
+*/
+
+package srtgears
+
+import (
+	"strings"
+	"time"
+)
+
+// Default reading-speed and timing thresholds, used by Stats and by Retime
+// whenever the corresponding RetimeOptions field is left at its zero value.
+const (
+	DefaultMinCPS      = 5.0                        // Characters/sec below this is considered too slow
+	DefaultMaxCPS      = 21.0                       // Characters/sec above this is considered too fast
+	DefaultMinWPM      = 90.0                       // Words/min below this is considered too slow
+	DefaultMaxWPM      = 180.0                      // Words/min above this is considered too fast
+	DefaultMinDuration = 1 * time.Second            // Shortest a subtitle should be displayed
+	DefaultMaxDuration = 7 * time.Second            // Longest a subtitle should be displayed
+	DefaultMinGap      = 83333333 * time.Nanosecond // ~2 frames at 24fps, the usual minimum gap between subs
+)
+
+// charsPerSecond computes the reading speed for a subtitle with the given
+// number of non-space characters and display duration. Returns 0 if dur <= 0.
+func charsPerSecond(nonSpaceChars int, dur time.Duration) float64 {
+	if dur <= 0 {
+		return 0
+	}
+	return float64(nonSpaceChars) / dur.Seconds()
+}
+
+// wordsPerMinute computes the reading speed for a subtitle with the given
+// number of words and display duration. Returns 0 if dur <= 0.
+func wordsPerMinute(words int, dur time.Duration) float64 {
+	if dur <= 0 {
+		return 0
+	}
+	return float64(words) / dur.Minutes()
+}
+
+// RetimeOptions configures SubsPack.Retime. A zero value for any duration,
+// CPS or WPM field means "use the corresponding Default* constant".
+type RetimeOptions struct {
+	MaxCPS      float64       // Subs read faster than this get lengthened
+	MaxWPM      float64       // Subs read faster than this (in words/min) get lengthened
+	MinDuration time.Duration // Shorter subs are lengthened to this; the MinGap pass may still trim a sub below it
+	MaxDuration time.Duration // Subs are never lengthened beyond this
+	MinGap      time.Duration // Minimum gap enforced between consecutive subs
+
+	// RespectPos, when true, leaves an overlapping pair of subs untouched if
+	// they have different Pos (e.g. one on top, one on the bottom), since
+	// that overlap is intentional (dual-language subtitles after Merge).
+	RespectPos bool
+}
+
+// withDefaults returns a copy of o with zero fields replaced by their default.
+func (o RetimeOptions) withDefaults() RetimeOptions {
+	if o.MaxCPS == 0 {
+		o.MaxCPS = DefaultMaxCPS
+	}
+	if o.MaxWPM == 0 {
+		o.MaxWPM = DefaultMaxWPM
+	}
+	if o.MinDuration == 0 {
+		o.MinDuration = DefaultMinDuration
+	}
+	if o.MaxDuration == 0 {
+		o.MaxDuration = DefaultMaxDuration
+	}
+	if o.MinGap == 0 {
+		o.MinGap = DefaultMinGap
+	}
+	return o
+}
+
+// Retime adjusts the display duration of subtitles that are read too fast,
+// caps overly long ones, and enforces a minimum gap between consecutive
+// subtitles, all within the constraints given by opts.
+//
+// Subtitles must be sorted (see Sort) before calling Retime.
+func (sp *SubsPack) Retime(opts RetimeOptions) {
+	opts = opts.withDefaults()
+
+	for i, s := range sp.Subs {
+		nonSpace, words := 0, 0
+		for _, line := range s.Lines {
+			for _, r := range line {
+				if r != ' ' && r != '\t' && r != '\n' {
+					nonSpace++
+				}
+			}
+			words += len(strings.Fields(line))
+		}
+
+		// Lengthen subs that are read too fast (by either measure), up to
+		// MaxDuration or the next sub's start (minus the minimum gap),
+		// whichever comes first.
+		wanted := s.DisplayDuration()
+		if cps := charsPerSecond(nonSpace, s.DisplayDuration()); cps > opts.MaxCPS {
+			if d := time.Duration(float64(nonSpace)/opts.MaxCPS*float64(time.Second)) + 1; d > wanted {
+				wanted = d
+			}
+		}
+		if wpm := wordsPerMinute(words, s.DisplayDuration()); wpm > opts.MaxWPM {
+			if d := time.Duration(float64(words)/opts.MaxWPM*float64(time.Minute)) + 1; d > wanted {
+				wanted = d
+			}
+		}
+		if wanted > s.DisplayDuration() {
+			if wanted > opts.MaxDuration {
+				wanted = opts.MaxDuration
+			}
+			s.TimeOut = s.TimeIn + wanted
+			if i+1 < len(sp.Subs) && !(opts.RespectPos && s.Pos != sp.Subs[i+1].Pos) {
+				if limit := sp.Subs[i+1].TimeIn - opts.MinGap; s.TimeOut > limit {
+					s.TimeOut = limit
+				}
+			}
+			if s.TimeOut < s.TimeIn {
+				s.TimeOut = s.TimeIn
+			}
+		}
+
+		// Shorten subs that are displayed for longer than allowed.
+		if s.DisplayDuration() > opts.MaxDuration {
+			s.TimeOut = s.TimeIn + opts.MaxDuration
+		}
+
+		// Never go below the minimum duration.
+		if s.DisplayDuration() < opts.MinDuration {
+			s.TimeOut = s.TimeIn + opts.MinDuration
+		}
+	}
+
+	// Enforce the minimum gap by trimming the TimeOut of the earlier sub.
+	for i := 0; i+1 < len(sp.Subs); i++ {
+		s, next := sp.Subs[i], sp.Subs[i+1]
+		if opts.RespectPos && s.Pos != next.Pos {
+			continue // Intentional overlap, e.g. dual-language subs on different positions
+		}
+		if gap := next.TimeIn - s.TimeOut; gap < opts.MinGap {
+			s.TimeOut = next.TimeIn - opts.MinGap
+			if s.TimeOut < s.TimeIn {
+				s.TimeOut = s.TimeIn
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package srtgears
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatsCPS(t *testing.T) {
+	sp := &SubsPack{Subs: []*Subtitle{
+		{TimeIn: 0, TimeOut: 200 * time.Millisecond, Lines: []string{"This line reads far too fast"}}, // high CPS
+		{TimeIn: 1 * time.Second, TimeOut: 11 * time.Second, Lines: []string{"Slow"}},                 // low CPS
+	}}
+
+	ss := sp.Stats()
+	if ss.HighCPS != 1 {
+		t.Errorf("HighCPS = %d, want 1", ss.HighCPS)
+	}
+	if ss.LowCPS != 1 {
+		t.Errorf("LowCPS = %d, want 1", ss.LowCPS)
+	}
+	if len(ss.Offenders) != 2 {
+		t.Errorf("len(Offenders) = %d, want 2", len(ss.Offenders))
+	}
+}
+
+func TestStatsWPM(t *testing.T) {
+	sp := &SubsPack{Subs: []*Subtitle{
+		{TimeIn: 0, TimeOut: 1 * time.Second, Lines: []string{"One two three four five six seven"}}, // high WPM
+		{TimeIn: 2 * time.Second, TimeOut: 12 * time.Second, Lines: []string{"Slow"}},               // low WPM
+	}}
+
+	ss := sp.Stats()
+	if ss.HighWPM != 1 {
+		t.Errorf("HighWPM = %d, want 1", ss.HighWPM)
+	}
+	if ss.LowWPM != 1 {
+		t.Errorf("LowWPM = %d, want 1", ss.LowWPM)
+	}
+}
+
+func TestStatsOverlapsAndShortGaps(t *testing.T) {
+	sp := &SubsPack{Subs: []*Subtitle{
+		{TimeIn: 0, TimeOut: 2 * time.Second, Lines: []string{"A reasonably long first line"}},
+		{TimeIn: 1 * time.Second, TimeOut: 3 * time.Second, Lines: []string{"Overlaps with the previous one"}},
+		{TimeIn: 3*time.Second + 10*time.Millisecond, TimeOut: 5 * time.Second, Lines: []string{"Gap is far too short here"}},
+	}}
+
+	ss := sp.Stats()
+	if ss.Overlaps != 1 {
+		t.Errorf("Overlaps = %d, want 1", ss.Overlaps)
+	}
+	if ss.ShortGaps != 1 {
+		t.Errorf("ShortGaps = %d, want 1", ss.ShortGaps)
+	}
+}
+
+func TestRetimeLengthensTooFastSub(t *testing.T) {
+	sp := &SubsPack{Subs: []*Subtitle{
+		{TimeIn: 0, TimeOut: 200 * time.Millisecond, Lines: []string{"This line reads far too fast"}},
+		{TimeIn: 5 * time.Second, TimeOut: 6 * time.Second, Lines: []string{"Next"}},
+	}}
+
+	sp.Retime(RetimeOptions{})
+
+	s := sp.Subs[0]
+	nonSpace := 0
+	for _, r := range s.Lines[0] {
+		if r != ' ' {
+			nonSpace++
+		}
+	}
+	cps := charsPerSecond(nonSpace, s.DisplayDuration())
+	if cps > DefaultMaxCPS+0.01 {
+		t.Errorf("after Retime, CPS = %v, still above DefaultMaxCPS (%v)", cps, DefaultMaxCPS)
+	}
+	if s.TimeOut > sp.Subs[1].TimeIn-DefaultMinGap {
+		t.Errorf("Retime lengthened sub 0 past sub 1's start minus the min gap: TimeOut=%v, limit=%v", s.TimeOut, sp.Subs[1].TimeIn-DefaultMinGap)
+	}
+}
+
+func TestRetimeLengthensTooFastSubByWPM(t *testing.T) {
+	sp := &SubsPack{Subs: []*Subtitle{
+		{TimeIn: 0, TimeOut: 1 * time.Second, Lines: []string{"One two three four five six seven"}},
+		{TimeIn: 10 * time.Second, TimeOut: 11 * time.Second, Lines: []string{"Next"}},
+	}}
+
+	sp.Retime(RetimeOptions{})
+
+	s := sp.Subs[0]
+	wpm := wordsPerMinute(7, s.DisplayDuration())
+	if wpm > DefaultMaxWPM+0.01 {
+		t.Errorf("after Retime, WPM = %v, still above DefaultMaxWPM (%v)", wpm, DefaultMaxWPM)
+	}
+}
+
+func TestRetimeShortensTooLongSub(t *testing.T) {
+	sp := &SubsPack{Subs: []*Subtitle{
+		{TimeIn: 0, TimeOut: 20 * time.Second, Lines: []string{"Short text"}},
+	}}
+	sp.Retime(RetimeOptions{MaxDuration: 5 * time.Second})
+	if sp.Subs[0].DisplayDuration() != 5*time.Second {
+		t.Errorf("DisplayDuration = %v, want 5s", sp.Subs[0].DisplayDuration())
+	}
+}
+
+func TestRetimeEnforcesMinGap(t *testing.T) {
+	sp := &SubsPack{Subs: []*Subtitle{
+		{TimeIn: 0, TimeOut: 2 * time.Second, Lines: []string{"One"}},
+		{TimeIn: 2*time.Second + 10*time.Millisecond, TimeOut: 4 * time.Second, Lines: []string{"Two"}},
+	}}
+	opts := RetimeOptions{MinGap: 100 * time.Millisecond}
+	sp.Retime(opts)
+
+	gap := sp.Subs[1].TimeIn - sp.Subs[0].TimeOut
+	if gap < opts.MinGap {
+		t.Errorf("gap after Retime = %v, want >= %v", gap, opts.MinGap)
+	}
+}
+
+func TestRetimeRespectsPosForOverlaps(t *testing.T) {
+	sp := &SubsPack{Subs: []*Subtitle{
+		{TimeIn: 0, TimeOut: 3 * time.Second, Pos: PosTopCenter, Lines: []string{"Top"}},
+		{TimeIn: 1 * time.Second, TimeOut: 3 * time.Second, Pos: PosBottomCenter, Lines: []string{"Bottom"}},
+	}}
+	sp.Retime(RetimeOptions{RespectPos: true, MinGap: 500 * time.Millisecond})
+
+	if sp.Subs[0].TimeOut != 3*time.Second {
+		t.Errorf("overlapping pair on different Pos got trimmed: TimeOut = %v, want unchanged 3s", sp.Subs[0].TimeOut)
+	}
+}
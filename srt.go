@@ -0,0 +1,324 @@
+/*
+
+This file implements the SRT (SubRip) Format: reading and writing
+the classic ".srt" subtitle files.
+
+*/
+
+package srtgears
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// srtFormat implements Format for the SRT subtitle format.
+type srtFormat struct{}
+
+func (srtFormat) Read(r io.Reader) (*SubsPack, error) {
+	return ReadSRT(r)
+}
+
+func (srtFormat) Write(w io.Writer, sp *SubsPack) error {
+	return writeSRT(w, sp)
+}
+
+// ReadStream implements StreamFormat: it parses blocks one at a time,
+// sending each Subtitle to out as soon as it's parsed instead of holding
+// the whole file's subtitles in memory at once.
+func (srtFormat) ReadStream(r io.Reader, out chan<- *Subtitle) error {
+	return readSRTStream(r, out, false)
+}
+
+// WriteStream implements StreamFormat: it writes each subtitle as it
+// arrives from in instead of requiring the whole pack up front.
+func (srtFormat) WriteStream(w io.Writer, in <-chan *Subtitle) error {
+	return writeSRTStream(w, in)
+}
+
+// srtTimingLineRegex matches a timing line such as "00:00:01,000 --> 00:00:04,000",
+// tolerating "." as the millisecond separator and trailing positioning info.
+// In its canonical form (comma separator, no trailing info) the timing part is
+// exactly 29 characters long.
+var srtTimingLineRegex = regexp.MustCompile(`\d{2}:\d{2}:\d{2}[,.]\d{3}\s*-->\s*\d{2}:\d{2}:\d{2}[,.]\d{3}`)
+
+// ReadSRT parses an SRT file leniently, tolerating the way real-world files
+// commonly deviate from the spec: a UTF-8/UTF-16 byte-order mark, missing or
+// duplicated sequence-number lines, mixed "\r\n"/"\n" line endings, and a
+// "." instead of "," as the millisecond separator.
+func ReadSRT(r io.Reader) (*SubsPack, error) {
+	return readSRT(r, false)
+}
+
+// ReadSRTStrict parses an SRT file, requiring every block to start with its
+// own sequence-number line as mandated by the original SRT spec.
+func ReadSRTStrict(r io.Reader) (*SubsPack, error) {
+	return readSRT(r, true)
+}
+
+// readSRT is the shared implementation behind ReadSRT and ReadSRTStrict.
+func readSRT(r io.Reader, strict bool) (*SubsPack, error) {
+	r, err := stripBOM(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sp := &SubsPack{}
+	scanner := bufio.NewScanner(r)
+
+	for {
+		block, ok := nextSRTBlock(scanner)
+		if !ok {
+			break
+		}
+		s, err := parseSRTBlock(block, strict)
+		if err != nil {
+			return nil, err
+		}
+		sp.Subs = append(sp.Subs, s)
+	}
+
+	return sp, scanner.Err()
+}
+
+// readSRTStream is like readSRT, but sends each Subtitle to out as soon as
+// its block is parsed instead of materializing the whole pack, closing out
+// when done (on clean EOF or on error).
+func readSRTStream(r io.Reader, out chan<- *Subtitle, strict bool) error {
+	defer close(out)
+
+	r, err := stripBOM(r)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(r)
+	for {
+		block, ok := nextSRTBlock(scanner)
+		if !ok {
+			break
+		}
+		s, err := parseSRTBlock(block, strict)
+		if err != nil {
+			return err
+		}
+		out <- s
+	}
+
+	return scanner.Err()
+}
+
+// parseSRTBlock converts a single non-blank run of lines, as produced by
+// nextSRTBlock, into a Subtitle, applying the same leniency rules as ReadSRT.
+func parseSRTBlock(block []string, strict bool) (*Subtitle, error) {
+	timingIdx := -1
+	for i, line := range block {
+		if srtTimingLineRegex.MatchString(line) {
+			timingIdx = i
+			break
+		}
+	}
+	if timingIdx == -1 {
+		return nil, fmt.Errorf("srtgears: block without a timing line: %v", block)
+	}
+
+	// Every line before the timing line must be a sequence-number line.
+	// Lenient mode additionally tolerates more than one of them (some
+	// encoders duplicate the index); strict mode requires exactly one,
+	// as mandated by the original SRT spec.
+	for _, line := range block[:timingIdx] {
+		if _, err := strconv.Atoi(strings.TrimSpace(line)); err != nil {
+			if strict {
+				return nil, fmt.Errorf("srtgears: expected sequence number, got %q", line)
+			}
+			return nil, fmt.Errorf("srtgears: unexpected line before timing line: %q", line)
+		}
+	}
+	if strict && timingIdx != 1 {
+		return nil, fmt.Errorf("srtgears: expected a single sequence-number line, got %d: %v", timingIdx, block[:timingIdx])
+	}
+
+	timeIn, timeOut, err := parseSRTTimingLine(block[timingIdx])
+	if err != nil {
+		return nil, err
+	}
+
+	return &Subtitle{TimeIn: timeIn, TimeOut: timeOut, Lines: block[timingIdx+1:]}, nil
+}
+
+// nextSRTBlock reads the next run of non-blank lines (an SRT block) from scanner,
+// skipping any number of blank lines first. Returns ok=false at EOF.
+func nextSRTBlock(scanner *bufio.Scanner) (block []string, ok bool) {
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		block = append(block, line)
+		break
+	}
+	if len(block) == 0 {
+		return nil, false
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		block = append(block, line)
+	}
+
+	return block, true
+}
+
+// stripBOM detects and removes a UTF-8 or UTF-16 (LE/BE) byte-order mark from
+// the start of r, transcoding UTF-16 input to UTF-8 in the process.
+func stripBOM(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case len(head) == 2 && head[0] == 0xFF && head[1] == 0xFE:
+		br.Discard(2)
+		return utf16ToUTF8Reader(br, false)
+	case len(head) == 2 && head[0] == 0xFE && head[1] == 0xFF:
+		br.Discard(2)
+		return utf16ToUTF8Reader(br, true)
+	}
+
+	head3, err := br.Peek(3)
+	if err == nil && bytes.Equal(head3, []byte{0xEF, 0xBB, 0xBF}) {
+		br.Discard(3)
+	}
+	return br, nil
+}
+
+// utf16ToUTF8Reader reads the remainder of r as UTF-16 (big-endian if bigEndian,
+// little-endian otherwise) and returns a reader over its UTF-8 transcoding.
+func utf16ToUTF8Reader(r io.Reader, bigEndian bool) (io.Reader, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	units := make([]uint16, 0, len(raw)/2)
+	for i := 0; i+1 < len(raw); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		} else {
+			units = append(units, uint16(raw[i+1])<<8|uint16(raw[i]))
+		}
+	}
+
+	return strings.NewReader(string(utf16.Decode(units))), nil
+}
+
+// formatSRTTimestamp formats d the SRT way: "HH:MM:SS,mmm".
+func formatSRTTimestamp(d time.Duration) string {
+	ms := d / time.Millisecond
+	h := ms / (3600 * 1000)
+	ms -= h * 3600 * 1000
+	m := ms / (60 * 1000)
+	ms -= m * 60 * 1000
+	s := ms / 1000
+	ms -= s * 1000
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", h, m, s, ms)
+}
+
+// parseSRTTimestamp parses a "HH:MM:SS,mmm" or "HH:MM:SS.mmm" timestamp.
+func parseSRTTimestamp(s string) (time.Duration, error) {
+	s = strings.ReplaceAll(s, ".", ",")
+	parts := strings.SplitN(s, ",", 2)
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("srtgears: invalid timestamp: %q", s)
+	}
+	hms := strings.Split(parts[0], ":")
+	if len(hms) != 3 {
+		return 0, fmt.Errorf("srtgears: invalid timestamp: %q", s)
+	}
+	h, err := strconv.Atoi(hms[0])
+	if err != nil {
+		return 0, err
+	}
+	m, err := strconv.Atoi(hms[1])
+	if err != nil {
+		return 0, err
+	}
+	sec, err := strconv.Atoi(hms[2])
+	if err != nil {
+		return 0, err
+	}
+	ms, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+		time.Duration(sec)*time.Second + time.Duration(ms)*time.Millisecond, nil
+}
+
+// parseSRTTimingLine parses a "00:00:01,000 --> 00:00:04,000" line.
+func parseSRTTimingLine(line string) (timeIn, timeOut time.Duration, err error) {
+	parts := strings.SplitN(line, "-->", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("srtgears: invalid timing line: %q", line)
+	}
+	timeIn, err = parseSRTTimestamp(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, err
+	}
+	// The out timestamp may be followed by positioning info, only the timestamp itself is needed
+	outField := strings.Fields(strings.TrimSpace(parts[1]))
+	if len(outField) == 0 {
+		return 0, 0, fmt.Errorf("srtgears: invalid timing line: %q", line)
+	}
+	timeOut, err = parseSRTTimestamp(outField[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	return timeIn, timeOut, nil
+}
+
+// writeSRT writes sp in the SRT format.
+func writeSRT(w io.Writer, sp *SubsPack) error {
+	bw := bufio.NewWriter(w)
+	for i, s := range sp.Subs {
+		writeSRTEntry(bw, i+1, s)
+	}
+	return bw.Flush()
+}
+
+// writeSRTStream is like writeSRT, but writes each subtitle as it arrives
+// from in instead of requiring the whole pack up front.
+func writeSRTStream(w io.Writer, in <-chan *Subtitle) error {
+	bw := bufio.NewWriter(w)
+	for i := 1; ; i++ {
+		s, ok := <-in
+		if !ok {
+			break
+		}
+		writeSRTEntry(bw, i, s)
+	}
+	return bw.Flush()
+}
+
+// writeSRTEntry writes a single SRT entry (sequence number, timing line and
+// text lines, followed by the blank separator line) to bw.
+func writeSRTEntry(bw *bufio.Writer, seq int, s *Subtitle) {
+	fmt.Fprintf(bw, "%d\n", seq)
+	fmt.Fprintf(bw, "%s --> %s\n", formatSRTTimestamp(s.TimeIn), formatSRTTimestamp(s.TimeOut))
+	for _, line := range s.Lines {
+		fmt.Fprintln(bw, line)
+	}
+	fmt.Fprintln(bw)
+}
@@ -0,0 +1,186 @@
+package srtgears
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+func TestReadSRTTolerant(t *testing.T) {
+	const in = "1\r\n00:00:01,000 --> 00:00:04,000\r\nHello\r\n\r\n" +
+		"00:00:05.000 --> 00:00:07.500\nNo index, dot separator\n"
+
+	sp, err := ReadSRT(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ReadSRT: %v", err)
+	}
+	if len(sp.Subs) != 2 {
+		t.Fatalf("len(Subs) = %d, want 2", len(sp.Subs))
+	}
+	if sp.Subs[0].Lines[0] != "Hello" {
+		t.Errorf("Subs[0].Lines[0] = %q, want %q", sp.Subs[0].Lines[0], "Hello")
+	}
+	if sp.Subs[1].TimeIn != 5*time.Second || sp.Subs[1].TimeOut != 7500*time.Millisecond {
+		t.Errorf("Subs[1] timing = %v --> %v, want 5s --> 7.5s", sp.Subs[1].TimeIn, sp.Subs[1].TimeOut)
+	}
+}
+
+func TestReadSRTDuplicatedIndex(t *testing.T) {
+	const in = "1\n1\n00:00:01,000 --> 00:00:04,000\nHello\n"
+	sp, err := ReadSRT(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ReadSRT: %v", err)
+	}
+	if len(sp.Subs) != 1 || sp.Subs[0].Lines[0] != "Hello" {
+		t.Errorf("Subs = %+v", sp.Subs)
+	}
+}
+
+func TestReadSRTStrictRejectsDuplicatedIndex(t *testing.T) {
+	const in = "1\n1\n00:00:01,000 --> 00:00:04,000\nHello\n"
+	if _, err := ReadSRTStrict(strings.NewReader(in)); err == nil {
+		t.Error("ReadSRTStrict: expected error for a duplicated sequence-number line, got nil")
+	}
+}
+
+func TestDetectDuplicatedIndex(t *testing.T) {
+	const in = "1\n1\n00:00:01,000 --> 00:00:04,000\nHello\n"
+	name, _, err := Detect(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if name != "srt" {
+		t.Errorf("Detect = %q, want %q", name, "srt")
+	}
+}
+
+func TestReadSRTMissingIndex(t *testing.T) {
+	const in = "00:00:01,000 --> 00:00:04,000\nNo index at all\n"
+	sp, err := ReadSRT(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ReadSRT: %v", err)
+	}
+	if len(sp.Subs) != 1 || sp.Subs[0].Lines[0] != "No index at all" {
+		t.Errorf("Subs = %+v", sp.Subs)
+	}
+}
+
+func TestReadSRTStrictRejectsMissingIndex(t *testing.T) {
+	const in = "00:00:01,000 --> 00:00:04,000\nNo index\n"
+	if _, err := ReadSRTStrict(strings.NewReader(in)); err == nil {
+		// Strict mode requires every block to start with its own index line,
+		// so a missing one is an error just like a garbled one.
+		t.Error("ReadSRTStrict: expected error for a missing sequence-number line, got nil")
+	}
+
+	const garbled = "not-a-number\n00:00:01,000 --> 00:00:04,000\nHello\n"
+	if _, err := ReadSRTStrict(strings.NewReader(garbled)); err == nil {
+		t.Error("ReadSRTStrict: expected error for a garbled sequence-number line, got nil")
+	}
+}
+
+func TestReadSRTUTF8BOM(t *testing.T) {
+	in := "\xEF\xBB\xBF1\n00:00:01,000 --> 00:00:04,000\nHello\n"
+	sp, err := ReadSRT(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ReadSRT: %v", err)
+	}
+	if len(sp.Subs) != 1 || sp.Subs[0].Lines[0] != "Hello" {
+		t.Errorf("Subs = %+v", sp.Subs)
+	}
+}
+
+func TestReadSRTUTF16BOM(t *testing.T) {
+	text := "1\r\n00:00:01,000 --> 00:00:04,000\r\nHello\r\n\r\n"
+	units := utf16.Encode([]rune(text))
+	raw := make([]byte, 2+2*len(units))
+	raw[0], raw[1] = 0xFF, 0xFE // UTF-16LE BOM
+	for i, u := range units {
+		raw[2+2*i] = byte(u)
+		raw[2+2*i+1] = byte(u >> 8)
+	}
+
+	sp, err := ReadSRT(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("ReadSRT: %v", err)
+	}
+	if len(sp.Subs) != 1 || sp.Subs[0].Lines[0] != "Hello" {
+		t.Errorf("Subs = %+v", sp.Subs)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"srt", "1\n00:00:01,000 --> 00:00:04,000\nHello\n", "srt"},
+		{"vtt", "WEBVTT\n\n00:00:01.000 --> 00:00:04.000\nHello\n", "vtt"},
+		{"ass", "[Script Info]\nScriptType: v4.00+\n", "ass"},
+	}
+	for _, c := range cases {
+		name, _, err := Detect(strings.NewReader(c.in))
+		if err != nil {
+			t.Errorf("%s: Detect: unexpected error: %v", c.name, err)
+			continue
+		}
+		if name != c.want {
+			t.Errorf("%s: Detect = %q, want %q", c.name, name, c.want)
+		}
+	}
+}
+
+func TestDetectReplaysConsumedBytes(t *testing.T) {
+	const in = "1\n00:00:01,000 --> 00:00:04,000\nHello\n\n" +
+		"2\n00:00:05,000 --> 00:00:06,000\nWorld\n"
+
+	name, r, err := Detect(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if name != "srt" {
+		t.Fatalf("Detect = %q, want %q", name, "srt")
+	}
+
+	f, err := FormatByName(name)
+	if err != nil {
+		t.Fatalf("FormatByName: %v", err)
+	}
+	sp, err := f.Read(r)
+	if err != nil {
+		t.Fatalf("Read on Detect's replay reader: %v", err)
+	}
+	if len(sp.Subs) != 2 || sp.Subs[1].Lines[0] != "World" {
+		t.Errorf("Subs after Detect+Read = %+v, want both subtitles intact", sp.Subs)
+	}
+}
+
+func TestDetectUTF16BOM(t *testing.T) {
+	text := "1\n00:00:01,000 --> 00:00:04,000\nHello\n"
+	units := utf16.Encode([]rune(text))
+	raw := make([]byte, 2+2*len(units))
+	raw[0], raw[1] = 0xFF, 0xFE
+	for i, u := range units {
+		raw[2+2*i] = byte(u)
+		raw[2+2*i+1] = byte(u >> 8)
+	}
+
+	name, r, err := Detect(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if name != "srt" {
+		t.Fatalf("Detect = %q, want %q", name, "srt")
+	}
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading replay reader: %v", err)
+	}
+	if string(got) != text {
+		t.Errorf("replay reader content = %q, want transcoded %q", got, text)
+	}
+}
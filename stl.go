@@ -0,0 +1,172 @@
+/*
+
+This file implements the EBU STL (EBU Tech 3264) Format.
+
+Only the commonly-used subset of the spec is implemented: the 1024-byte
+GSI (General Subtitle Information) block followed by 128-byte TTI (Text
+and Timing Information) blocks, using a fixed "STL25.01" (25fps, no drop
+frame) disk format. Extended/teletext-only GSI fields that have no
+counterpart in SubsPack/Metadata are written as spaces and ignored on read.
+
+*/
+
+package srtgears
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// gsiBlockSize and ttiBlockSize are fixed by the EBU STL spec.
+const (
+	gsiBlockSize = 1024
+	ttiBlockSize = 128
+)
+
+// stlDFCOffset is the byte offset of the GSI "Disk Format Code" field.
+// EBU STL has no magic number, but the DFC field is always "STLxx.yy",
+// which Detect uses to sniff the format.
+const stlDFCOffset = 3
+
+// stlFormat implements Format for the EBU STL subtitle format.
+type stlFormat struct{}
+
+func (stlFormat) Read(r io.Reader) (*SubsPack, error) {
+	gsi := make([]byte, gsiBlockSize)
+	if _, err := io.ReadFull(r, gsi); err != nil {
+		return nil, fmt.Errorf("srtgears: reading GSI block: %v", err)
+	}
+
+	sp := &SubsPack{}
+	sp.Metadata.Language = strings.TrimSpace(string(gsi[14:16]))
+	sp.Metadata.Title = strings.TrimSpace(string(gsi[16:48]))
+	sp.Metadata.FrameRate = 25 // Fixed for the STL25.01 format we write/assume
+
+	tti := make([]byte, ttiBlockSize)
+	for {
+		_, err := io.ReadFull(r, tti)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		timeIn := stlTimecodeToDuration(tti[5:9], sp.Metadata.FrameRate)
+		timeOut := stlTimecodeToDuration(tti[9:13], sp.Metadata.FrameRate)
+
+		text := tti[16:128]
+		// 0x8F pads the rest of the last block; trim it off first.
+		if i := indexByte(text, 0x8F); i >= 0 {
+			text = text[:i]
+		}
+		var lines []string
+		for _, part := range splitByte(text, 0x8A) {
+			lines = append(lines, string(part))
+		}
+
+		sp.Subs = append(sp.Subs, &Subtitle{TimeIn: timeIn, TimeOut: timeOut, Lines: lines})
+	}
+
+	return sp, nil
+}
+
+func (stlFormat) Write(w io.Writer, sp *SubsPack) error {
+	// Timecodes are encoded at 25fps to match the STL25.01 disk format code
+	// written below, which is also the rate Read decodes them at.
+	const fps = 25.0
+
+	gsi := make([]byte, gsiBlockSize)
+	for i := range gsi {
+		gsi[i] = ' '
+	}
+	copy(gsi[stlDFCOffset:], "STL25.01")
+	copy(gsi[14:16], padRight(sp.Metadata.Language, 2))
+	copy(gsi[16:48], padRight(sp.Metadata.Title, 32))
+	copy(gsi[238:243], fmt.Sprintf("%05d", len(sp.Subs))) // TNB: total number of TTI blocks
+	if _, err := w.Write(gsi); err != nil {
+		return err
+	}
+
+	for i, s := range sp.Subs {
+		tti := make([]byte, ttiBlockSize)
+		for j := range tti {
+			tti[j] = 0x8F
+		}
+		tti[0] = 0 // SGN: subtitle group number
+		tti[1], tti[2] = byte(i), byte(i>>8)
+		durationToSTLTimecode(s.TimeIn, fps, tti[5:9])
+		durationToSTLTimecode(s.TimeOut, fps, tti[9:13])
+		tti[13] = 0 // VP: vertical position, left at default (top)
+		tti[14] = 0 // JC: justification code, left at default (centered)
+		tti[15] = 0 // CF: comment flag
+
+		text := strings.Join(s.Lines, "\x8a")
+		copy(tti[16:128], text)
+
+		if _, err := w.Write(tti); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func padRight(s string, n int) string {
+	if len(s) >= n {
+		return s[:n]
+	}
+	return s + strings.Repeat(" ", n-len(s))
+}
+
+func indexByte(b []byte, c byte) int {
+	for i, v := range b {
+		if v == c {
+			return i
+		}
+	}
+	return -1
+}
+
+func splitByte(b []byte, sep byte) [][]byte {
+	var parts [][]byte
+	start := 0
+	for i, v := range b {
+		if v == sep {
+			parts = append(parts, b[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, b[start:])
+	return parts
+}
+
+// stlTimecodeToDuration decodes a 4-byte BCD HH:MM:SS:FF timecode at the given frame rate.
+func stlTimecodeToDuration(tc []byte, fps float64) time.Duration {
+	h, m, s, f := int(tc[0]), int(tc[1]), int(tc[2]), int(tc[3])
+	secs := float64(h*3600+m*60+s) + float64(f)/fps
+	return time.Duration(secs * float64(time.Second))
+}
+
+// durationToSTLTimecode encodes d as a 4-byte HH:MM:SS:FF timecode at the given frame rate into dst.
+func durationToSTLTimecode(d time.Duration, fps float64, dst []byte) {
+	totalFrames := int(d.Seconds() * fps)
+	framesPerSec := int(fps)
+	f := totalFrames % framesPerSec
+	totalSecs := totalFrames / framesPerSec
+	s := totalSecs % 60
+	m := (totalSecs / 60) % 60
+	h := totalSecs / 3600
+	dst[0], dst[1], dst[2], dst[3] = byte(h), byte(m), byte(s), byte(f)
+}
+
+// looksLikeSTL reports whether the first gsiBlockSize bytes of b carry the
+// "STL" disk-format-code signature expected at a fixed GSI offset.
+func looksLikeSTL(b []byte) bool {
+	if len(b) < stlDFCOffset+3 {
+		return false
+	}
+	return string(b[stlDFCOffset:stlDFCOffset+3]) == "STL"
+}
@@ -16,7 +16,8 @@ import (
 // SubsPack represents subtitles of a movie,
 // a collection of Subtitles and other meta info.
 type SubsPack struct {
-	Subs []*Subtitle
+	Subs     []*Subtitle
+	Metadata Metadata // Format-agnostic metadata (framerate, language, title), round-tripped where the format supports it
 }
 
 // Type that implements sorting
@@ -61,16 +62,22 @@ func (sp *SubsPack) SetColor(color string) {
 }
 
 // RemoveHI removes hearing impaired lines from subtitles
-// (such as "[PHONE RINGING]" or "(phone ringing)").
+// (such as "[PHONE RINGING]" or "(phone ringing)"), dropping subtitles
+// that end up with no lines left. A single forward compaction pass,
+// so this is O(N) rather than the O(N²) an in-place slice-shift per
+// removal would cost.
 func (sp *SubsPack) RemoveHI() {
-	for i := len(sp.Subs) - 1; i >= 0; i-- {
-		s := sp.Subs[i]
+	w := 0
+	for _, s := range sp.Subs {
 		s.RemoveHI()
 		if len(s.Lines) == 0 {
-			// Can be removed completely
-			sp.Subs = append(sp.Subs[:i], sp.Subs[i+1:]...)
+			// Can be dropped completely
+			continue
 		}
+		sp.Subs[w] = s
+		w++
 	}
+	sp.Subs = sp.Subs[:w]
 }
 
 // Concatenate concatenates another SubsPack to this.
@@ -92,39 +99,123 @@ func (sp *SubsPack) Concatenate(sp2 *SubsPack, secPartStart time.Duration) {
 	sp.Sort()
 }
 
+// MergeOptions configures SubsPack.Merge. Pos/Color fields left at their
+// zero value (PosUnset / "") leave the corresponding pack's subtitles
+// untouched instead of overwriting them.
+type MergeOptions struct {
+	TopPos, BottomPos     Pos    // Position to force onto the receiver's ("top") and the argument's ("bottom") subtitles
+	TopColor, BottomColor string // Color to force onto the receiver's and the argument's subtitles
+
+	// CollapseSimultaneous, when set, merges a top/bottom pair of subtitles
+	// whose time windows overlap (within Tolerance) into a single Subtitle
+	// whose Lines are the concatenation (top pack's lines first), instead
+	// of emitting them as two separate, simultaneously-visible entries.
+	CollapseSimultaneous bool
+	Tolerance            time.Duration // Max gap between a pair still considered simultaneous; 0 means a 500ms default
+}
+
+func (o MergeOptions) tolerance() time.Duration {
+	if o.Tolerance == 0 {
+		return 500 * time.Millisecond
+	}
+	return o.Tolerance
+}
+
 // Merge merges another SubsPack into this to create a "dual subtitle".
-// Subtitles are not copied, only their addresses are merged to ours.
+// Subtitles are not copied, only their addresses are merged to ours
+// (unless CollapseSimultaneous produces a new, combined Subtitle).
 //
 // Useful if 2 different subtitles are to be displayed at the same time, e.g. 2 different languages.
-func (sp *SubsPack) Merge(sp2 *SubsPack) {
+func (sp *SubsPack) Merge(sp2 *SubsPack, opts MergeOptions) {
 	// Make sure inputs are properly ordered
 	sp.Sort()
 	sp2.Sort()
 
-	// Guards to prevent null pointer access
-	l1 := len(sp.Subs)
-	numSubs := l1 + len(sp2.Subs)
-
-	// Output container
-	merged := make([]*Subtitle, numSubs)
+	if opts.TopPos != PosUnset {
+		sp.SetPos(opts.TopPos)
+	}
+	if opts.BottomPos != PosUnset {
+		sp2.SetPos(opts.BottomPos)
+	}
+	if opts.TopColor != "" {
+		sp.SetColor(opts.TopColor)
+	}
+	if opts.BottomColor != "" {
+		sp2.SetColor(opts.BottomColor)
+	}
 
-	// Indicies for iteration
-	p1, p2 := 0, 0
+	l1, l2 := len(sp.Subs), len(sp2.Subs)
+	merged := make([]*Subtitle, 0, l1+l2)
+	fromTop := make([]bool, 0, l1+l2) // Parallel slice: whether merged[i] came from sp (the receiver, "top")
 
 	// Step through to do a stable merge
-	for i := 0; i < numSubs; i++ {
-		if p1 < l1 && sp.Subs[p1].TimeIn <= sp2.Subs[p2].TimeIn {
-			merged[i] = sp.Subs[p1]
+	p1, p2 := 0, 0
+	for p1 < l1 || p2 < l2 {
+		if p2 >= l2 || (p1 < l1 && sp.Subs[p1].TimeIn <= sp2.Subs[p2].TimeIn) {
+			merged = append(merged, sp.Subs[p1])
+			fromTop = append(fromTop, true)
 			p1++
 		} else {
-			merged[i] = sp2.Subs[p2]
+			merged = append(merged, sp2.Subs[p2])
+			fromTop = append(fromTop, false)
 			p2++
 		}
 	}
 
+	if opts.CollapseSimultaneous {
+		merged = collapseSimultaneous(merged, fromTop, opts.tolerance())
+	}
+
 	// Write results back into sp
-	sp.Subs = make([]*Subtitle, numSubs)
-	copy(sp.Subs, merged)
+	sp.Subs = merged
+}
+
+// collapseSimultaneous merges adjacent top/bottom pairs in merged whose time
+// windows overlap within tol into a single Subtitle (top pack's lines first).
+func collapseSimultaneous(merged []*Subtitle, fromTop []bool, tol time.Duration) []*Subtitle {
+	out := make([]*Subtitle, 0, len(merged))
+	for i := 0; i < len(merged); i++ {
+		if i+1 < len(merged) && fromTop[i] != fromTop[i+1] && simultaneous(merged[i], merged[i+1], tol) {
+			top, bottom := merged[i], merged[i+1]
+			if !fromTop[i] {
+				top, bottom = bottom, top
+			}
+			out = append(out, &Subtitle{
+				TimeIn:  minDuration(top.TimeIn, bottom.TimeIn),
+				TimeOut: maxDuration(top.TimeOut, bottom.TimeOut),
+				Pos:     top.Pos,
+				Color:   top.Color,
+				Lines:   append(append([]string{}, top.Lines...), bottom.Lines...),
+			})
+			i++
+			continue
+		}
+		out = append(out, merged[i])
+	}
+	return out
+}
+
+// simultaneous reports whether a and b's display windows overlap, or are
+// separated by no more than tol.
+func simultaneous(a, b *Subtitle, tol time.Duration) bool {
+	if a.TimeIn > b.TimeIn {
+		a, b = b, a
+	}
+	return b.TimeIn <= a.TimeOut+tol
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
 }
 
 // Split splits this SubsPack into 2 at the specified time.
@@ -188,46 +279,111 @@ type SubsStats struct {
 	HTMLs                     int           // # of subs having HTML formatting
 	Controls                  int           // # of subs having controls
 	HIs                       int           // # of subs having hearing impaired lines
+	HighCPS                   int           // # of subs read faster than the max reading speed (too fast)
+	LowCPS                    int           // # of subs read slower than the min reading speed (too slow)
+	HighWPM                   int           // # of subs read faster than the max words/min reading speed (too fast)
+	LowWPM                    int           // # of subs read slower than the min words/min reading speed (too slow)
+	Overlaps                  int           // # of subs whose display time overlaps the next sub's
+	ShortGaps                 int           // # of subs whose gap to the next sub is below the min gap
+	Offenders                 []*Subtitle   // Subs flagged by any of the above reading-speed / timing checks
 }
 
 // Stats analyzes the subtitle pack and returns various statistics.
 // Subtitles will be modified so you should not attempt to save it after calling this.
 func (sp *SubsPack) Stats() *SubsStats {
-	ss := SubsStats{
-		Subs: len(sp.Subs),
+	ss := &SubsStats{}
+
+	for i, s := range sp.Subs {
+		offender := ss.addSub(s)
+
+		if i+1 < len(sp.Subs) {
+			gap := sp.Subs[i+1].TimeIn - s.TimeOut
+			switch {
+			case gap < 0:
+				ss.Overlaps++
+				offender = true
+			case gap < DefaultMinGap:
+				ss.ShortGaps++
+				offender = true
+			}
+		}
+		if offender {
+			ss.Offenders = append(ss.Offenders, s)
+		}
 	}
 
-	for _, s := range sp.Subs {
-		ss.TotalDispDur += s.DisplayDuration()
-		ss.Lines += len(s.Lines)
+	var lastTimeOut time.Duration
+	if len(sp.Subs) > 0 {
+		lastTimeOut = sp.Subs[len(sp.Subs)-1].TimeOut
+	}
+	ss.finalizeAverages(lastTimeOut)
+	return ss
+}
 
-		if s.RemoveControl() {
-			ss.Controls++
-		}
-		if s.RemoveHTML() {
-			ss.HTMLs++
-		}
+// addSub folds a single subtitle's line/word/char and HTML/control/HI/CPS/WPM
+// counters into ss, leaving gap/overlap detection (which needs a neighbor)
+// to the caller. It reports whether s is a reading-speed offender on its
+// own account (HighCPS/LowCPS/HighWPM/LowWPM).
+func (ss *SubsStats) addSub(s *Subtitle) (offender bool) {
+	ss.Subs++
+	ss.TotalDispDur += s.DisplayDuration()
+	ss.Lines += len(s.Lines)
+
+	if s.RemoveControl() {
+		ss.Controls++
+	}
+	if s.RemoveHTML() {
+		ss.HTMLs++
+	}
 
-		for _, v := range s.Lines {
-			ss.Chars += utf8.RuneCountInString(v)
-			fields := strings.Fields(v)
-			ss.Words += len(fields)
-			for _, v2 := range fields {
-				ss.CharsNoSpace += utf8.RuneCountInString(v2)
-			}
+	nonSpace, words := 0, 0
+	for _, v := range s.Lines {
+		ss.Chars += utf8.RuneCountInString(v)
+		fields := strings.Fields(v)
+		ss.Words += len(fields)
+		words += len(fields)
+		for _, v2 := range fields {
+			n := utf8.RuneCountInString(v2)
+			ss.CharsNoSpace += n
+			nonSpace += n
 		}
+	}
 
-		if s.RemoveHI() {
-			ss.HIs++
-		}
+	if s.RemoveHI() {
+		ss.HIs++
 	}
 
-	if len(sp.Subs) > 0 {
-		if last := sp.Subs[len(sp.Subs)-1].TimeOut; last != 0 {
-			ss.SubVisibRatio = float64(ss.TotalDispDur) / float64(last)
+	if cps := charsPerSecond(nonSpace, s.DisplayDuration()); cps > 0 {
+		if cps > DefaultMaxCPS {
+			ss.HighCPS++
+			offender = true
+		} else if cps < DefaultMinCPS {
+			ss.LowCPS++
+			offender = true
+		}
+	}
+	if wpm := wordsPerMinute(words, s.DisplayDuration()); wpm > 0 {
+		if wpm > DefaultMaxWPM {
+			ss.HighWPM++
+			offender = true
+		} else if wpm < DefaultMinWPM {
+			ss.LowWPM++
+			offender = true
 		}
 	}
+	return offender
+}
 
+// finalizeAverages computes the averages and ratios that can only be known
+// once all subtitles have been folded in, lastTimeOut being the TimeOut of
+// the last subtitle (used for SubVisibRatio).
+func (ss *SubsStats) finalizeAverages(lastTimeOut time.Duration) {
+	if ss.Subs == 0 {
+		return
+	}
+	if lastTimeOut != 0 {
+		ss.SubVisibRatio = float64(ss.TotalDispDur) / float64(lastTimeOut)
+	}
 	ss.AvgLinesPerSub = float64(ss.Lines) / float64(ss.Subs)
 	ss.AvgCharsPerLine = float64(ss.CharsNoSpace) / float64(ss.Lines)
 	ss.AvgWordsPerLine = float64(ss.Words) / float64(ss.Lines)
@@ -235,5 +391,4 @@ func (sp *SubsPack) Stats() *SubsStats {
 	if ss.CharsNoSpace > 0 {
 		ss.AvgDispDurPerNonSpaceChar = ss.TotalDispDur / time.Duration(ss.CharsNoSpace)
 	}
-	return &ss
 }
@@ -0,0 +1,55 @@
+package srtgears
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergeAssignsPosAndColor(t *testing.T) {
+	top := &SubsPack{Subs: []*Subtitle{{TimeIn: 0, TimeOut: time.Second, Lines: []string{"Hello"}}}}
+	bottom := &SubsPack{Subs: []*Subtitle{{TimeIn: 2 * time.Second, TimeOut: 3 * time.Second, Lines: []string{"Hola"}}}}
+
+	top.Merge(bottom, MergeOptions{
+		TopPos: PosTopCenter, BottomPos: PosBottomCenter,
+		TopColor: "#ffffff", BottomColor: "#ffff00",
+	})
+
+	if len(top.Subs) != 2 {
+		t.Fatalf("len(Subs) = %d, want 2", len(top.Subs))
+	}
+	if top.Subs[0].Pos != PosTopCenter || top.Subs[0].Color != "#ffffff" {
+		t.Errorf("Subs[0] Pos/Color = %v/%v, want %v/%v", top.Subs[0].Pos, top.Subs[0].Color, PosTopCenter, "#ffffff")
+	}
+	if top.Subs[1].Pos != PosBottomCenter || top.Subs[1].Color != "#ffff00" {
+		t.Errorf("Subs[1] Pos/Color = %v/%v, want %v/%v", top.Subs[1].Pos, top.Subs[1].Color, PosBottomCenter, "#ffff00")
+	}
+}
+
+func TestMergeCollapseSimultaneous(t *testing.T) {
+	top := &SubsPack{Subs: []*Subtitle{{TimeIn: 0, TimeOut: 2 * time.Second, Lines: []string{"Hello"}}}}
+	bottom := &SubsPack{Subs: []*Subtitle{{TimeIn: 100 * time.Millisecond, TimeOut: 2 * time.Second, Lines: []string{"Hola"}}}}
+
+	top.Merge(bottom, MergeOptions{CollapseSimultaneous: true})
+
+	if len(top.Subs) != 1 {
+		t.Fatalf("len(Subs) = %d, want 1 (overlapping pair should collapse)", len(top.Subs))
+	}
+	if got := strings.Join(top.Subs[0].Lines, "|"); got != "Hello|Hola" {
+		t.Errorf("Lines = %q, want %q (top pack's lines first)", got, "Hello|Hola")
+	}
+	if top.Subs[0].TimeIn != 0 || top.Subs[0].TimeOut != 2*time.Second {
+		t.Errorf("TimeIn/TimeOut = %v/%v, want 0/2s", top.Subs[0].TimeIn, top.Subs[0].TimeOut)
+	}
+}
+
+func TestMergeCollapseSimultaneousRespectsToleranceAndNonOverlap(t *testing.T) {
+	top := &SubsPack{Subs: []*Subtitle{{TimeIn: 0, TimeOut: 1 * time.Second, Lines: []string{"Hello"}}}}
+	bottom := &SubsPack{Subs: []*Subtitle{{TimeIn: 5 * time.Second, TimeOut: 6 * time.Second, Lines: []string{"Hola"}}}}
+
+	top.Merge(bottom, MergeOptions{CollapseSimultaneous: true})
+
+	if len(top.Subs) != 2 {
+		t.Fatalf("len(Subs) = %d, want 2 (non-overlapping pair should not collapse)", len(top.Subs))
+	}
+}
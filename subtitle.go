@@ -0,0 +1,126 @@
+/*
+
+This file defines the Subtitle model type and its utility methods.
+
+*/
+
+package srtgears
+
+import (
+	"regexp"
+	"time"
+)
+
+// Pos represents a subtitle's display position,
+// mirroring the ASS/SSA \anX alignment codes (1-9, numpad layout).
+type Pos int
+
+// Possible positions. PosUnset means the subtitle uses the player's default position.
+const (
+	PosUnset Pos = iota
+	PosBottomLeft
+	PosBottomCenter
+	PosBottomRight
+	PosMiddleLeft
+	PosMiddleCenter
+	PosMiddleRight
+	PosTopLeft
+	PosTopCenter
+	PosTopRight
+)
+
+// Subtitle represents a single, displayable unit of a subtitle file:
+// a time interval during which the given lines of text are displayed.
+type Subtitle struct {
+	TimeIn, TimeOut time.Duration // Time interval in which the subtitle is visible
+	Pos             Pos           // Display position; PosUnset if not specified
+	Color           string        // Color of the text (e.g. "#ffffff" or an HTML color name); empty if unset
+	Lines           []string      // Lines of text to display
+	Words           [][]Word      // Per-word karaoke timing for each of Lines; nil if the subtitle has none
+}
+
+// Word represents the timing of a single word within a karaoke-timed line,
+// Start/End being relative to the owning Subtitle's TimeIn.
+type Word struct {
+	Text       string
+	Start, End time.Duration
+}
+
+// DisplayDuration returns the duration for which the subtitle is visible.
+func (s *Subtitle) DisplayDuration() time.Duration {
+	return s.TimeOut - s.TimeIn
+}
+
+// Shift shifts the subtitle with the specified delta.
+func (s *Subtitle) Shift(delta time.Duration) {
+	s.TimeIn += delta
+	s.TimeOut += delta
+}
+
+// Scale scales the timestamps of the subtitle.
+// The duration for which the subtitle is visible is not changed.
+func (s *Subtitle) Scale(factor float64) {
+	dur := s.DisplayDuration()
+	s.TimeIn = time.Duration(float64(s.TimeIn) * factor)
+	s.TimeOut = s.TimeIn + dur
+}
+
+// Lengthen lengthens the display duration of the subtitle.
+func (s *Subtitle) Lengthen(factor float64) {
+	s.TimeOut = s.TimeIn + time.Duration(float64(s.DisplayDuration())*factor)
+}
+
+// hiLineRegex matches a line that consists entirely of a hearing-impaired
+// annotation, e.g. "[PHONE RINGING]" or "(phone ringing)".
+var hiLineRegex = regexp.MustCompile(`^\s*[\[(].*[\])]\s*$`)
+
+// RemoveHI removes hearing impaired lines from the subtitle
+// (such as "[PHONE RINGING]" or "(phone ringing)").
+// It reports whether any line was removed.
+func (s *Subtitle) RemoveHI() bool {
+	removed := false
+	lines := make([]string, 0, len(s.Lines))
+	for _, line := range s.Lines {
+		if hiLineRegex.MatchString(line) {
+			removed = true
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if removed {
+		s.Lines = lines
+	}
+	return removed
+}
+
+// htmlTagRegex matches an HTML tag such as "<i>" or "</font>".
+var htmlTagRegex = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+
+// RemoveHTML removes HTML formatting from the subtitle's lines.
+// It reports whether anything was removed.
+func (s *Subtitle) RemoveHTML() bool {
+	changed := false
+	for i, line := range s.Lines {
+		if nl := htmlTagRegex.ReplaceAllString(line, ""); nl != line {
+			s.Lines[i] = nl
+			changed = true
+		}
+	}
+	return changed
+}
+
+// controlRegex matches an ASS/SSA-style override block such as "{\an5}" or "{\pos(10,20)}".
+var controlRegex = regexp.MustCompile(`\{\\[^}]*\}`)
+
+// RemoveControl removes controls such as {\anX} (or {\aY}), {\pos(x,y)} from the subtitle.
+// It reports whether anything was removed.
+func (s *Subtitle) RemoveControl() bool {
+	changed := false
+	for i, line := range s.Lines {
+		if nl := controlRegex.ReplaceAllString(line, ""); nl != line {
+			s.Lines[i] = nl
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,171 @@
+/*
+
+This file implements the TTML/DFXP Format.
+
+Only the subset of TTML relevant to SubsPack is handled: the <body><div><p>
+cue structure with begin/end timestamps, and the head/metadata elements
+used to round-trip Metadata.
+
+*/
+
+package srtgears
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ttmlFormat implements Format for the TTML/DFXP subtitle format.
+type ttmlFormat struct{}
+
+type ttmlDocument struct {
+	XMLName   xml.Name `xml:"tt"`
+	Lang      string   `xml:"lang,attr"`      // xml:lang; matched by local name regardless of its xml: prefix
+	FrameRate string   `xml:"frameRate,attr"` // ttp:frameRate; matched by local name regardless of its ttp: prefix
+	Head      ttmlHead `xml:"head"`
+	Body      ttmlBody `xml:"body"`
+}
+
+type ttmlHead struct {
+	Title string `xml:"metadata>title"`
+}
+
+type ttmlBody struct {
+	Divs []ttmlDiv `xml:"div"`
+}
+
+type ttmlDiv struct {
+	Paragraphs []ttmlP `xml:"p"`
+}
+
+type ttmlP struct {
+	Begin string `xml:"begin,attr"`
+	End   string `xml:"end,attr"`
+	Text  string `xml:",innerxml"`
+}
+
+func (ttmlFormat) Read(r io.Reader) (*SubsPack, error) {
+	var doc ttmlDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("srtgears: parsing TTML: %v", err)
+	}
+
+	sp := &SubsPack{}
+	sp.Metadata.Language = doc.Lang
+	sp.Metadata.Title = doc.Head.Title
+	if doc.FrameRate != "" {
+		sp.Metadata.FrameRate, _ = strconv.ParseFloat(doc.FrameRate, 64)
+	}
+
+	for _, div := range doc.Body.Divs {
+		for _, p := range div.Paragraphs {
+			timeIn, err := parseTTMLTimestamp(p.Begin)
+			if err != nil {
+				return nil, err
+			}
+			timeOut, err := parseTTMLTimestamp(p.End)
+			if err != nil {
+				return nil, err
+			}
+			text := strings.ReplaceAll(p.Text, "<br/>", "\n")
+			text = strings.ReplaceAll(text, "<br></br>", "\n")
+			text = xmlUnescape(text)
+			sp.Subs = append(sp.Subs, &Subtitle{TimeIn: timeIn, TimeOut: timeOut, Lines: strings.Split(text, "\n")})
+		}
+	}
+
+	return sp, nil
+}
+
+func (ttmlFormat) Write(w io.Writer, sp *SubsPack) error {
+	fmt.Fprintln(w, `<?xml version="1.0" encoding="UTF-8"?>`)
+	lang := sp.Metadata.Language
+	if sp.Metadata.FrameRate != 0 {
+		fmt.Fprintf(w, `<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttp="http://www.w3.org/ns/ttml#parameter" xml:lang="%s" ttp:frameRate="%s">`+"\n",
+			lang, formatTTMLFrameRate(sp.Metadata.FrameRate))
+	} else {
+		fmt.Fprintf(w, `<tt xmlns="http://www.w3.org/ns/ttml" xml:lang="%s">`+"\n", lang)
+	}
+	fmt.Fprintln(w, "  <head>")
+	if sp.Metadata.Title != "" {
+		fmt.Fprintf(w, "    <metadata><title>%s</title></metadata>\n", xmlEscape(sp.Metadata.Title))
+	}
+	fmt.Fprintln(w, "  </head>")
+	fmt.Fprintln(w, "  <body>")
+	fmt.Fprintln(w, "    <div>")
+	for _, s := range sp.Subs {
+		escaped := make([]string, len(s.Lines))
+		for i, line := range s.Lines {
+			escaped[i] = xmlEscape(line)
+		}
+		text := strings.Join(escaped, "<br/>")
+		fmt.Fprintf(w, `      <p begin="%s" end="%s">%s</p>`+"\n",
+			formatTTMLTimestamp(s.TimeIn), formatTTMLTimestamp(s.TimeOut), text)
+	}
+	fmt.Fprintln(w, "    </div>")
+	fmt.Fprintln(w, "  </body>")
+	fmt.Fprintln(w, "</tt>")
+	return nil
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// xmlUnescapeReplacer reverses the entities xml.EscapeText (used by xmlEscape)
+// produces; it is applied to the innerxml of a <p>, whose "<br/>" line-break
+// tags are handled separately and so are never mistaken for entities here.
+var xmlUnescapeReplacer = strings.NewReplacer(
+	"&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'",
+	"&#34;", `"`, "&#39;", "'", "&#x9;", "\t", "&#xA;", "\n", "&#xD;", "\r",
+	"&amp;", "&",
+)
+
+func xmlUnescape(s string) string {
+	return xmlUnescapeReplacer.Replace(s)
+}
+
+// parseTTMLTimestamp parses a "HH:MM:SS.mmm" clock-time timestamp.
+func parseTTMLTimestamp(s string) (time.Duration, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("srtgears: invalid TTML timestamp: %q", s)
+	}
+	h, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, err
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, err
+	}
+	secF, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+		time.Duration(secF*float64(time.Second)), nil
+}
+
+// formatTTMLFrameRate formats fps without unnecessary trailing zeros, e.g. 25 or 29.97.
+func formatTTMLFrameRate(fps float64) string {
+	return strconv.FormatFloat(fps, 'f', -1, 64)
+}
+
+// formatTTMLTimestamp formats d as a "HH:MM:SS.mmm" clock-time timestamp.
+func formatTTMLTimestamp(d time.Duration) string {
+	ms := d / time.Millisecond
+	h := ms / (3600 * 1000)
+	ms -= h * 3600 * 1000
+	m := ms / (60 * 1000)
+	ms -= m * 60 * 1000
+	s := ms / 1000
+	ms -= s * 1000
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)
+}
@@ -0,0 +1,166 @@
+/*
+
+This file implements the WebVTT Format.
+
+*/
+
+package srtgears
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// vttFormat implements Format for the WebVTT subtitle format.
+type vttFormat struct{}
+
+func (vttFormat) Read(r io.Reader) (*SubsPack, error) {
+	sp := &SubsPack{}
+
+	scanner := bufio.NewScanner(r)
+	if err := scanVTTHeader(scanner); err != nil {
+		return nil, err
+	}
+
+	for {
+		s, ok, err := nextVTTSubtitle(scanner)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		sp.Subs = append(sp.Subs, s)
+	}
+
+	return sp, scanner.Err()
+}
+
+func (vttFormat) Write(w io.Writer, sp *SubsPack) error {
+	bw := bufio.NewWriter(w)
+	writeVTTHeader(bw)
+	for _, s := range sp.Subs {
+		writeVTTEntry(bw, s)
+	}
+	return bw.Flush()
+}
+
+// ReadStream implements StreamFormat: it parses cues one at a time, sending
+// each Subtitle to out as soon as it's parsed instead of holding the whole
+// file's subtitles in memory at once.
+func (vttFormat) ReadStream(r io.Reader, out chan<- *Subtitle) error {
+	defer close(out)
+
+	scanner := bufio.NewScanner(r)
+	if err := scanVTTHeader(scanner); err != nil {
+		return err
+	}
+
+	for {
+		s, ok, err := nextVTTSubtitle(scanner)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		out <- s
+	}
+
+	return scanner.Err()
+}
+
+// WriteStream implements StreamFormat: it writes each subtitle as it
+// arrives from in instead of requiring the whole pack up front.
+func (vttFormat) WriteStream(w io.Writer, in <-chan *Subtitle) error {
+	bw := bufio.NewWriter(w)
+	writeVTTHeader(bw)
+	for s := range in {
+		writeVTTEntry(bw, s)
+	}
+	return bw.Flush()
+}
+
+// scanVTTHeader consumes the mandatory leading "WEBVTT" line from scanner.
+func scanVTTHeader(scanner *bufio.Scanner) error {
+	if !scanner.Scan() {
+		return scanner.Err()
+	}
+	if !strings.HasPrefix(strings.TrimSpace(scanner.Text()), "WEBVTT") {
+		return fmt.Errorf("srtgears: not a WebVTT file")
+	}
+	return nil
+}
+
+// nextVTTSubtitle scans past blank lines, cue identifiers and NOTE/STYLE
+// blocks, then parses and returns the next cue. Returns ok=false at EOF.
+func nextVTTSubtitle(scanner *bufio.Scanner) (s *Subtitle, ok bool, err error) {
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || !strings.Contains(line, "-->") {
+			continue // blank line, cue identifier or NOTE/STYLE block
+		}
+
+		timeIn, timeOut, err := parseVTTTimingLine(line)
+		if err != nil {
+			return nil, false, err
+		}
+
+		var lines []string
+		for scanner.Scan() {
+			l := scanner.Text()
+			if strings.TrimSpace(l) == "" {
+				break
+			}
+			lines = append(lines, l)
+		}
+
+		return &Subtitle{TimeIn: timeIn, TimeOut: timeOut, Lines: lines}, true, nil
+	}
+	return nil, false, nil
+}
+
+// writeVTTHeader writes the mandatory leading "WEBVTT" line.
+func writeVTTHeader(bw *bufio.Writer) {
+	fmt.Fprintln(bw, "WEBVTT")
+	fmt.Fprintln(bw)
+}
+
+// writeVTTEntry writes a single cue (timing line and text lines, followed
+// by the blank separator line) to bw.
+func writeVTTEntry(bw *bufio.Writer, s *Subtitle) {
+	fmt.Fprintf(bw, "%s --> %s\n", formatVTTTimestamp(s.TimeIn), formatVTTTimestamp(s.TimeOut))
+	for _, line := range s.Lines {
+		fmt.Fprintln(bw, line)
+	}
+	fmt.Fprintln(bw)
+}
+
+// formatVTTTimestamp formats d the WebVTT way: "HH:MM:SS.mmm".
+func formatVTTTimestamp(d time.Duration) string {
+	return strings.ReplaceAll(formatSRTTimestamp(d), ",", ".")
+}
+
+// parseVTTTimingLine parses a "00:00:01.000 --> 00:00:04.000 <settings>" line.
+func parseVTTTimingLine(line string) (timeIn, timeOut time.Duration, err error) {
+	parts := strings.SplitN(line, "-->", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("srtgears: invalid VTT timing line: %q", line)
+	}
+	timeIn, err = parseSRTTimestamp(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, err
+	}
+	outField := strings.Fields(strings.TrimSpace(parts[1]))
+	if len(outField) == 0 {
+		return 0, 0, fmt.Errorf("srtgears: invalid VTT timing line: %q", line)
+	}
+	timeOut, err = parseSRTTimestamp(outField[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	return timeIn, timeOut, nil
+}